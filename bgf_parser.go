@@ -1,8 +1,31 @@
 package bgfparser
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
+
+	"github.com/kevung/bgfparser/internal/smile"
+)
+
+// Sentinel errors wrapped by ParseError when SMILE decoding cannot start
+// or the input is rejected, for use with errors.Is
+var (
+	// ErrInvalidSmileHeader reports SMILE data missing the ":)\n" magic header
+	ErrInvalidSmileHeader = smile.ErrInvalidHeader
+
+	// ErrUnsupportedSmileVersion reports a SMILE format version other than 0
+	ErrUnsupportedSmileVersion = smile.ErrUnsupportedVersion
+
+	// ErrTruncatedBGF reports a compressed payload that ends before the gzip
+	// stream is complete. The Match returned alongside holds any partial Data.
+	ErrTruncatedBGF = errors.New("BGF payload truncated")
+
+	// ErrTooLarge reports input longer than ParseOptions.MaxBytes
+	ErrTooLarge = errors.New("input exceeds the maximum size")
 )
 
 // ParseBGF parses a BGBlitz BGF (binary match) file from disk
@@ -23,15 +46,122 @@ func ParseBGF(filename string) (*Match, error) {
 		// Add filename to error if not already present
 		if parseErr, ok := err.(*ParseError); ok && parseErr.File == "" {
 			parseErr.File = filename
-			return nil, parseErr
+			return match, parseErr
 		}
-		return nil, err
+		return match, err
 	}
 
 	return match, nil
 }
 
-// GetMatchInfo extracts basic match information from a parsed BGF file
+// matchJSONKeys lists the JSON keys mapped to Match fields. Other keys found
+// when unmarshaling, such as extra header fields, go to HeaderExtras.
+var matchJSONKeys = map[string]bool{
+	"format":            true,
+	"version":           true,
+	"compress":          true,
+	"useSmile":          true,
+	"header_extras":     true,
+	"data":              true,
+	"raw_value":         true,
+	"decoding_warnings": true,
+	"partial":           true,
+}
+
+// headerKeys lists the BGF header keys, which are matched regardless of case
+// (e.g. "Compress" or "UseSMILE")
+var headerKeys = []string{"format", "version", "compress", "useSmile"}
+
+// headerKey returns the header key matching key regardless of case, or ""
+func headerKey(key string) string {
+	for _, k := range headerKeys {
+		if strings.EqualFold(k, key) {
+			return k
+		}
+	}
+	return ""
+}
+
+// UnmarshalJSON decodes a Match, collecting unknown keys in HeaderExtras.
+// Header keys are matched regardless of case; when a key appears with
+// several casings, the exact one wins.
+func (m *Match) UnmarshalJSON(data []byte) error {
+	// plainMatch has Match's fields without its methods, avoiding recursion.
+	// encoding/json matches its fields regardless of case.
+	type plainMatch Match
+	var plain plainMatch
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	exact := make(map[string]json.RawMessage)
+	folded := false
+	for key, raw := range fields {
+		if canonical := headerKey(key); canonical != "" {
+			if canonical == key {
+				exact[key] = raw
+			} else {
+				folded = true
+			}
+			continue
+		}
+		if matchJSONKeys[key] {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		if plain.HeaderExtras == nil {
+			plain.HeaderExtras = make(map[string]interface{})
+		}
+		plain.HeaderExtras[key] = value
+	}
+
+	// encoding/json keeps the last of several casings of a key
+	if folded && len(exact) > 0 {
+		exactData, err := json.Marshal(exact)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(exactData, &plain); err != nil {
+			return err
+		}
+	}
+
+	*m = Match(plain)
+	return nil
+}
+
+// matchInfoFields lists, for each normalized GetMatchInfo key, the data paths
+// where BGBlitz and other writers store that value, in order of preference.
+// Paths are matched case-insensitively.
+var matchInfoFields = []struct {
+	key   string
+	paths []string
+}{
+	{"playerGreen", []string{"nameGreen", "playerGreen", "playerO", "player1", "players.0.name"}},
+	{"playerRed", []string{"nameRed", "playerRed", "playerX", "player2", "players.1.name"}},
+	{"matchLength", []string{"matchlen", "matchLength", "length", "match.length"}},
+	{"scoreGreen", []string{"finalGreen", "scoreGreen"}},
+	{"scoreRed", []string{"finalRed", "scoreRed"}},
+	{"date", []string{"date", "matchDate"}},
+	{"event", []string{"event", "tournament"}},
+}
+
+// legacyMatchInfoKeys lists the top-level data keys GetMatchInfo has always
+// copied as is, kept for existing callers
+var legacyMatchInfoKeys = []string{"playerX", "playerO", "matchLength", "score", "date", "event"}
+
+// GetMatchInfo extracts basic match information from a parsed BGF file.
+// Besides the header fields, player names ("playerGreen", "playerRed"),
+// "matchLength", final scores ("scoreGreen", "scoreRed"), "date" and "event"
+// are included when found under any of their known key names. The top-level
+// "playerX", "playerO" and "score" keys are also copied as is when present.
 func (m *Match) GetMatchInfo() map[string]interface{} {
 	info := make(map[string]interface{})
 	info["format"] = m.Format
@@ -39,20 +169,69 @@ func (m *Match) GetMatchInfo() map[string]interface{} {
 	info["compress"] = m.Compress
 	info["useSmile"] = m.UseSmile
 
-	// Try to extract common fields from the data
-	if m.Data != nil {
-		for key, value := range m.Data {
-			// Only extract top-level metadata
-			switch key {
-			case "playerX", "playerO", "matchLength", "score", "date", "event":
-				info[key] = value
-			}
+	for _, key := range legacyMatchInfoKeys {
+		if value, ok := m.Data[key]; ok {
+			info[key] = value
+		}
+	}
+
+	// Try to extract common fields from the data, normalized values taking
+	// precedence over the legacy copies
+	for _, field := range matchInfoFields {
+		value, ok := m.lookupFirst(field.paths)
+		if !ok {
+			continue
+		}
+		switch value.(type) {
+		case int64, int, float64, float32:
+			info[field.key] = intValue(value)
+		default:
+			info[field.key] = value
 		}
 	}
 
 	return info
 }
 
+// KeyValue is one entry of the match information
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+// MatchInfoOrdered returns the entries of GetMatchInfo in a stable order:
+// the header fields ("format", "version", "compress", "useSmile") first,
+// then the other fields sorted by key
+func (m *Match) MatchInfoOrdered() []KeyValue {
+	info := m.GetMatchInfo()
+	entries := make([]KeyValue, 0, len(info))
+	for _, key := range headerKeys {
+		entries = append(entries, KeyValue{key, info[key]})
+		delete(info, key)
+	}
+
+	keys := make([]string, 0, len(info))
+	for key := range info {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entries = append(entries, KeyValue{key, info[key]})
+	}
+	return entries
+}
+
+// lookupFirst returns the first non-null value found at one of the data paths,
+// matching keys case-insensitively
+func (m *Match) lookupFirst(paths []string) (interface{}, bool) {
+	for _, path := range paths {
+		if value, ok := m.lookupPath(path, true); ok && value != nil {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
 // String returns a human-readable representation of the match
 func (m *Match) String() string {
 	info := m.GetMatchInfo()
@@ -0,0 +1,101 @@
+package bgfparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The BGBlitz ASCII board has five rows per half around the middle "BAR"
+// row. Each point and the bar take a 3-column cell whose middle column
+// holds the checkers; stacks taller than a half show their count in the
+// innermost row.
+const boardHalfRows = 5
+
+const (
+	boardTopBorder    = " +13-14-15-16-17-18------19-20-21-22-23-24-+"
+	boardBottomBorder = " +12-11-10--9--8--7-------6--5--4--3--2--1-+"
+	boardEmptyRow     = " |                  |   |                  |"
+	boardBarRow       = "v|                  |BAR|                  |"
+)
+
+// boardCellColumns lists the middle column of each cell of a board row,
+// left to right: six points, the bar, six points
+var boardCellColumns = [13]int{3, 6, 9, 12, 15, 18, 22, 26, 29, 32, 35, 38, 41}
+
+// topBoardPoints and bottomBoardPoints give the point (numbered from X's
+// side) shown in each cell of the top and bottom halves, 0 for the bar
+var (
+	topBoardPoints    = [13]int{13, 14, 15, 16, 17, 18, 0, 19, 20, 21, 22, 23, 24}
+	bottomBoardPoints = [13]int{12, 11, 10, 9, 8, 7, 0, 6, 5, 4, 3, 2, 1}
+)
+
+// ToBoardASCII renders the board as BGBlitz draws it in TXT exports,
+// including the player lines and the cube box of an owned cube. X's
+// checkers on the bar are drawn in the top half and O's in the bottom
+// half. Parsing the result without an XGID yields the same Board and OnBar.
+func (p *Position) ToBoardASCII() string {
+	rows := make([][]byte, 2*boardHalfRows+1)
+	for i := range rows {
+		rows[i] = []byte(boardEmptyRow)
+	}
+	rows[boardHalfRows] = []byte(boardBarRow)
+
+	for c, col := range boardCellColumns {
+		// Top rows stack downwards from the border, bottom rows upwards
+		p.drawBoardCell(rows, col, topBoardPoints[c], "X", func(i int) int { return i })
+		p.drawBoardCell(rows, col, bottomBoardPoints[c], "O", func(i int) int { return len(rows) - 1 - i })
+	}
+
+	// An owned cube sits in a box next to its owner's half
+	if !p.CubeIsCentered() {
+		box := []int{1, 2, 3}
+		if p.CubeOwner == "X" {
+			box = []int{9, 8, 7}
+		}
+		rows[box[0]] = append(rows[box[0]], " +--+"...)
+		rows[box[1]] = append(rows[box[1]], fmt.Sprintf(" |%2d|", p.CubeValue)...)
+		rows[box[2]] = append(rows[box[2]], " +--+"...)
+	}
+
+	var b strings.Builder
+	b.WriteString(boardTopBorder + p.boardPlayerLabel("O") + "\n")
+	for _, row := range rows {
+		b.Write(row)
+		b.WriteByte('\n')
+	}
+	b.WriteString(boardBottomBorder + p.boardPlayerLabel("X") + "\n")
+	return b.String()
+}
+
+// drawBoardCell draws the checkers of point (the bar of barPlayer when 0)
+// in the cell at col, row(i) giving the i-th row from the border
+func (p *Position) drawBoardCell(rows [][]byte, col, point int, barPlayer string, row func(int) int) {
+	player, count := barPlayer, p.OnBar[barPlayer]
+	if point > 0 {
+		player, count = "X", p.Board[point]
+		if count < 0 {
+			player, count = "O", -count
+		}
+	}
+	for i := 0; i < min(count, boardHalfRows); i++ {
+		rows[row(i)][col] = player[0]
+	}
+	if count > boardHalfRows {
+		s := strconv.Itoa(count)
+		copy(rows[row(boardHalfRows-1)][col+1-len(s):], s)
+	}
+}
+
+// boardPlayerLabel returns the name and pip count printed after the border
+// line of player's side, e.g. "   O: Green  52"
+func (p *Position) boardPlayerLabel(player string) string {
+	name := p.PlayerX
+	if player == "O" {
+		name = p.PlayerO
+	}
+	if name == "" {
+		return ""
+	}
+	return fmt.Sprintf("   %s: %s  %d", player, name, p.computePipCount(player))
+}
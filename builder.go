@@ -0,0 +1,95 @@
+package bgfparser
+
+// NewPosition returns an empty position with its maps initialized and a
+// centered cube at 1, ready to be filled in with the chainable With setters:
+//
+//	pos := bgfparser.NewPosition().
+//		WithBoard(board).
+//		WithOnRoll("X").
+//		WithDice(3, 1).
+//		WithMatchLength(7).
+//		WithScore(2, 4)
+//
+// The With setters also work on a zero Position, creating its maps as needed.
+func NewPosition() *Position {
+	return &Position{
+		CubeValue: 1,
+		OnBar:     map[string]int{"X": 0, "O": 0},
+		PipCount:  map[string]int{"X": 0, "O": 0},
+		Off:       map[string]int{"X": 15, "O": 15},
+	}
+}
+
+// WithBoard sets the board, laid out like Position.Board (X's checkers
+// positive, O's negative, points numbered from X's side). As in an XGID,
+// board[25] holds X's checkers on the bar and board[0] O's, as a positive
+// or negative count. Pip counts and borne-off checkers are recomputed.
+func (p *Position) WithBoard(board [26]int) *Position {
+	p.initMaps()
+	p.OnBar["X"] = max(board[25], 0)
+	p.OnBar["O"] = max(-board[0], 0)
+	board[0], board[25] = 0, 0
+	p.Board = board
+
+	for _, player := range []string{"X", "O"} {
+		p.PipCount[player] = p.computePipCount(player)
+		p.Off[player] = p.BorneOff(player)
+	}
+	return p
+}
+
+// WithOnRoll sets the player on roll ("X" or "O")
+func (p *Position) WithOnRoll(player string) *Position {
+	p.initMaps()
+	p.OnRoll = player
+	return p
+}
+
+// WithScore sets the match score
+func (p *Position) WithScore(scoreX, scoreO int) *Position {
+	p.initMaps()
+	p.ScoreX, p.ScoreO = scoreX, scoreO
+	updateCrawfordState(p)
+	return p
+}
+
+// WithDice sets the dice rolled by the player on roll (0, 0 when not rolled)
+func (p *Position) WithDice(d1, d2 int) *Position {
+	p.initMaps()
+	p.Dice = Dice{d1, d2}
+	return p
+}
+
+// WithCube sets the cube value and owner ("X", "O" or "" for a centered
+// cube). A cube at 1 is always centered.
+func (p *Position) WithCube(value int, owner string) *Position {
+	p.initMaps()
+	p.CubeValue = value
+	p.CubeOwner = owner
+	if p.CubeIsCentered() {
+		p.CubeOwner = ""
+	}
+	return p
+}
+
+// WithMatchLength sets the match length (0 for a money game)
+func (p *Position) WithMatchLength(length int) *Position {
+	p.initMaps()
+	p.MatchLength = length
+	updateCrawfordState(p)
+	return p
+}
+
+// initMaps creates the OnBar, PipCount and Off maps of a position built
+// without NewPosition
+func (p *Position) initMaps() {
+	if p.OnBar == nil {
+		p.OnBar = make(map[string]int)
+	}
+	if p.PipCount == nil {
+		p.PipCount = make(map[string]int)
+	}
+	if p.Off == nil {
+		p.Off = make(map[string]int)
+	}
+}
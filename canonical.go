@@ -0,0 +1,114 @@
+package bgfparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ToCanonicalJSON serializes the Match into a reproducible, compact JSON form:
+// object keys are sorted at every level, numbers are written in a single
+// format (integral values without a fraction, others in shortest form) and
+// diagnostics (DecodingWarnings, Partial and "_"-prefixed keys) are omitted.
+// The same match always produces byte-for-byte identical output.
+func (m *Match) ToCanonicalJSON() ([]byte, error) {
+	doc := map[string]interface{}{
+		"format":   m.Format,
+		"version":  m.Version,
+		"compress": m.Compress,
+		"useSmile": m.UseSmile,
+	}
+	if m.Data != nil {
+		doc["data"] = m.Data
+	} else if m.RawValue != nil {
+		doc["raw_value"] = m.RawValue
+	}
+
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeCanonical writes v as canonical JSON
+func writeCanonical(buf *bytes.Buffer, v interface{}) error {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(node))
+		for k := range node {
+			if !strings.HasPrefix(k, "_") {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, k); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, node[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case *OrderedMap:
+		return writeCanonical(buf, unordered(node))
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, child := range node {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, child); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case float64:
+		return writeCanonicalFloat(buf, node)
+	case float32:
+		return writeCanonicalFloat(buf, float64(node))
+	case int64:
+		buf.WriteString(strconv.FormatInt(node, 10))
+	case int:
+		buf.WriteString(strconv.Itoa(node))
+	case *big.Int:
+		buf.WriteString(node.String())
+	case *big.Float:
+		f, _ := node.Float64()
+		return writeCanonicalFloat(buf, f)
+	default:
+		// Strings, booleans, null and binary values
+		data, err := json.Marshal(node)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+// writeCanonicalFloat writes integral values without a fraction and others
+// in their shortest exact decimal form
+func writeCanonicalFloat(buf *bytes.Buffer, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("cannot encode %v as JSON", f)
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1e15 {
+		buf.WriteString(strconv.FormatInt(int64(f), 10))
+		return nil
+	}
+	buf.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+	return nil
+}
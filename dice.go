@@ -0,0 +1,80 @@
+package bgfparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Dice holds the two dice of a roll, [0, 0] when the dice are not rolled.
+// It encodes to JSON as a two-number array.
+type Dice [2]int
+
+// Valid reports whether both dice show a value from 1 to 6
+func (d Dice) Valid() bool {
+	return d[0] >= 1 && d[0] <= 6 && d[1] >= 1 && d[1] <= 6
+}
+
+// IsDouble reports whether both dice show the same value
+func (d Dice) IsDouble() bool {
+	return d.Valid() && d[0] == d[1]
+}
+
+// String returns the roll as written in position files, e.g. "3-1"
+func (d Dice) String() string {
+	return fmt.Sprintf("%d-%d", d[0], d[1])
+}
+
+// UnmarshalJSON accepts the [3, 1] array written by MarshalJSON, as well as
+// a "3-1" string
+func (d *Dice) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		var parsed Dice
+		if _, err := fmt.Sscanf(s, "%d-%d", &parsed[0], &parsed[1]); err != nil {
+			return fmt.Errorf("invalid dice %q", s)
+		}
+		*d = parsed
+		return nil
+	}
+
+	var values [2]int
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*d = values
+	return nil
+}
+
+// MarshalJSON encodes the dice as a two-number array, like [2]int
+func (d Dice) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]int(d))
+}
+
+// AllRolls returns the 21 distinct dice rolls, higher die first as in a
+// normalized Position. Use RollWeight for the number of the 36 equally
+// likely outcomes each roll stands for.
+func AllRolls() [][2]int {
+	rolls := make([][2]int, 0, 21)
+	for high := 1; high <= 6; high++ {
+		for low := 1; low <= high; low++ {
+			rolls = append(rolls, [2]int{high, low})
+		}
+	}
+	return rolls
+}
+
+// RollWeight returns how many of the 36 outcomes of two dice give the roll:
+// 1 for doubles, 2 for other rolls, 0 for invalid dice
+func RollWeight(dice [2]int) int {
+	if dice[0] < 1 || dice[0] > 6 || dice[1] < 1 || dice[1] > 6 {
+		return 0
+	}
+	if dice[0] == dice[1] {
+		return 1
+	}
+	return 2
+}
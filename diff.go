@@ -0,0 +1,129 @@
+package bgfparser
+
+import "fmt"
+
+// PositionDiff compares two analyses of the same position, e.g. by two engines.
+// Deltas are always B minus A.
+type PositionDiff struct {
+	Key string `json:"key"` // CanonicalKey shared by both positions
+
+	// Moves evaluated in both analyses, in A's order. Moves are matched
+	// regardless of notation, so "19/18, 14/12" matches "14/12 19/18".
+	Moves   []MoveDiff `json:"moves,omitempty"`
+	OnlyInA []string   `json:"only_in_a,omitempty"` // Moves evaluated only in A
+	OnlyInB []string   `json:"only_in_b,omitempty"` // Moves evaluated only in B
+
+	BestMoveA       string `json:"best_move_a,omitempty"`
+	BestMoveB       string `json:"best_move_b,omitempty"`
+	BestMoveChanged bool   `json:"best_move_changed"`
+
+	// Cube decisions found in both analyses, in A's order
+	CubeDecisions []CubeDecisionDiff `json:"cube_decisions,omitempty"`
+
+	BestCubeActionA  string `json:"best_cube_action_a,omitempty"`
+	BestCubeActionB  string `json:"best_cube_action_b,omitempty"`
+	CubeDisagreement bool   `json:"cube_disagreement"` // The best cube actions differ
+}
+
+// MoveDiff compares the evaluation of one move in both analyses
+type MoveDiff struct {
+	Move    string  `json:"move"` // As written in A
+	RankA   int     `json:"rank_a"`
+	RankB   int     `json:"rank_b"`
+	EquityA float64 `json:"equity_a"`
+	EquityB float64 `json:"equity_b"`
+	Delta   float64 `json:"delta"`
+}
+
+// CubeDecisionDiff compares one cube action in both analyses
+type CubeDecisionDiff struct {
+	Action   string  `json:"action"` // As written in A
+	MWCA     float64 `json:"mwc_a"`
+	MWCB     float64 `json:"mwc_b"`
+	MWCDelta float64 `json:"mwc_delta"`
+	EMGA     float64 `json:"emg_a"`
+	EMGB     float64 `json:"emg_b"`
+	EMGDelta float64 `json:"emg_delta"`
+}
+
+// DiffPositions compares the analyses of two positions with the same board,
+// player on roll, dice, cube and score. It returns an error if the positions
+// have different CanonicalKeys.
+func DiffPositions(a, b *Position) (*PositionDiff, error) {
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("cannot diff a nil position")
+	}
+	keyA, keyB := a.CanonicalKey(), b.CanonicalKey()
+	if keyA != keyB {
+		return nil, fmt.Errorf("positions differ: %s vs %s", keyA, keyB)
+	}
+
+	diff := &PositionDiff{Key: keyA}
+
+	matched := make(map[int]bool)
+	for _, ea := range a.Evaluations {
+		j := evaluationIndex(b.Evaluations, ea.Move)
+		if j < 0 {
+			diff.OnlyInA = append(diff.OnlyInA, ea.Move)
+			continue
+		}
+		matched[j] = true
+		eb := b.Evaluations[j]
+		diff.Moves = append(diff.Moves, MoveDiff{
+			Move:    ea.Move,
+			RankA:   ea.Rank,
+			RankB:   eb.Rank,
+			EquityA: ea.Equity,
+			EquityB: eb.Equity,
+			Delta:   eb.Equity - ea.Equity,
+		})
+	}
+	for j, eb := range b.Evaluations {
+		if !matched[j] {
+			diff.OnlyInB = append(diff.OnlyInB, eb.Move)
+		}
+	}
+
+	if len(a.Evaluations) > 0 && len(b.Evaluations) > 0 {
+		diff.BestMoveA = a.Evaluations[0].Move
+		diff.BestMoveB = b.Evaluations[0].Move
+		diff.BestMoveChanged = moveKey(diff.BestMoveA) != moveKey(diff.BestMoveB)
+	}
+
+	for _, da := range a.CubeDecisions {
+		kind := cubeActionKind(da.Action)
+		for _, db := range b.CubeDecisions {
+			if kind == "" || cubeActionKind(db.Action) != kind {
+				continue
+			}
+			diff.CubeDecisions = append(diff.CubeDecisions, CubeDecisionDiff{
+				Action:   da.Action,
+				MWCA:     da.MWC,
+				MWCB:     db.MWC,
+				MWCDelta: db.MWC - da.MWC,
+				EMGA:     da.EMG,
+				EMGB:     db.EMG,
+				EMGDelta: db.EMG - da.EMG,
+			})
+			break
+		}
+	}
+
+	diff.BestCubeActionA = bestCubeAction(a.CubeDecisions)
+	diff.BestCubeActionB = bestCubeAction(b.CubeDecisions)
+	if diff.BestCubeActionA != "" && diff.BestCubeActionB != "" {
+		diff.CubeDisagreement = cubeActionKind(diff.BestCubeActionA) != cubeActionKind(diff.BestCubeActionB)
+	}
+
+	return diff, nil
+}
+
+// bestCubeAction returns the action of the cube decision marked best, or ""
+func bestCubeAction(decisions []CubeDecision) string {
+	for _, d := range decisions {
+		if d.IsBest {
+			return d.Action
+		}
+	}
+	return ""
+}
@@ -114,15 +114,11 @@ func main() {
 			fmt.Printf("\nTotal decoded fields: %d\n", len(keys))
 		}
 
-		info := match.GetMatchInfo()
-		showOtherInfo := false
-		for key, value := range info {
-			if key != "format" && key != "version" && key != "compress" && key != "useSmile" {
-				if !showOtherInfo {
-					fmt.Println("\n--- Other Information ---")
-					showOtherInfo = true
-				}
-				fmt.Printf("%s: %v\n", key, value)
+		// The four header fields come first and were printed above
+		if info := match.MatchInfoOrdered(); len(info) > 4 {
+			fmt.Println("\n--- Other Information ---")
+			for _, kv := range info[4:] {
+				fmt.Printf("%s: %v\n", kv.Key, kv.Value)
 			}
 		}
 
@@ -7,6 +7,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -41,6 +42,9 @@ type PositionSummary struct {
 	BestMoveEquity float64 `json:"best_move_equity,omitempty"`
 }
 
+// maxUploadSize is the largest file accepted by the upload handlers
+const maxUploadSize = 10 << 20
+
 // uploadBGFHandler handles BGF file uploads
 func uploadBGFHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -49,7 +53,7 @@ func uploadBGFHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse multipart form (10 MB max)
-	err := r.ParseMultipartForm(10 << 20)
+	err := r.ParseMultipartForm(maxUploadSize)
 	if err != nil {
 		http.Error(w, "Failed to parse form", http.StatusBadRequest)
 		return
@@ -63,15 +67,13 @@ func uploadBGFHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	// Read file into memory
-	fileData, err := io.ReadAll(file)
-	if err != nil {
-		http.Error(w, "Failed to read file", http.StatusInternalServerError)
+	// Parse the BGF file straight from the upload, rejecting oversized files
+	opts := bgfparser.BGFOptions{ParseOptions: bgfparser.ParseOptions{MaxBytes: maxUploadSize}}
+	match, err := bgfparser.ParseBGFFromReaderWithOptions(file, opts)
+	if errors.Is(err, bgfparser.ErrTooLarge) {
+		http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
 		return
 	}
-
-	// Parse the BGF file
-	match, err := bgfparser.ParseBGFFromReader(bytes.NewReader(fileData))
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to parse BGF file: %v", err), http.StatusBadRequest)
 		return
@@ -194,12 +196,9 @@ func uploadTXTHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Add best move info
-	for _, eval := range pos.Evaluations {
-		if eval.IsBest {
-			summary.BestMove = eval.Move
-			summary.BestMoveEquity = eval.Equity
-			break
-		}
+	if best, ok := pos.BestMove(); ok {
+		summary.BestMove = best.Move
+		summary.BestMoveEquity = best.Equity
 	}
 
 	// Add filename
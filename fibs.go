@@ -0,0 +1,114 @@
+package bgfparser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// fibsUnlimitedMatch is the match length FIBS uses for unlimited (money) play
+const fibsUnlimitedMatch = 9999
+
+// fibsColor returns the FIBS color of a player: -1 for X, 1 for O
+func fibsColor(player string) int {
+	if player == "O" {
+		return 1
+	}
+	return -1
+}
+
+// ToFIBSBoard encodes the position as a FIBS "board:" message seen by the
+// given player ("X" or "O"). Following the FIBS client protocol, positions
+// 1-24 are numbered from that player's side, who moves towards position 0
+// with checkers of the sign of their color (-1 for X, 1 for O) and whose bar
+// is position 25. Player names default to "You" and "Opponent" when unknown.
+func (p *Position) ToFIBSBoard(you string) string {
+	if you != "O" {
+		you = "X"
+	}
+	opp := opponent(you)
+	color := fibsColor(you)
+
+	name := func(player, fallback string) string {
+		n := p.PlayerX
+		if player == "O" {
+			n = p.PlayerO
+		}
+		n = strings.ReplaceAll(strings.TrimSpace(n), ":", "")
+		if n == "" {
+			return fallback
+		}
+		return n
+	}
+	score := func(player string) int {
+		if player == "O" {
+			return p.ScoreO
+		}
+		return p.ScoreX
+	}
+	boolInt := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	matchLength := p.MatchLength
+	if matchLength <= 0 {
+		matchLength = fibsUnlimitedMatch
+	}
+
+	fields := []string{"board", name(you, "You"), name(opp, "Opponent")}
+	ints := []int{matchLength, score(you), score(opp)}
+
+	// Board: the opponent's bar, points 1-24, then the player's bar
+	ints = append(ints, -color*p.OnBar[opp])
+	for point := 1; point <= 24; point++ {
+		ints = append(ints, p.Board[boardIndex(you, point)]*playerSign(you)*color)
+	}
+	ints = append(ints, color*p.OnBar[you])
+
+	// Turn, then the player's and the opponent's dice
+	turn := 0
+	if p.OnRoll == "X" || p.OnRoll == "O" {
+		turn = fibsColor(p.OnRoll)
+	}
+	var yourDice, oppDice Dice
+	switch p.OnRoll {
+	case you:
+		yourDice = p.Dice
+	case opp:
+		oppDice = p.Dice
+	}
+	ints = append(ints, turn, yourDice[0], yourDice[1], oppDice[0], oppDice[1])
+
+	cube := p.CubeValue
+	if cube < 1 {
+		cube = 1
+	}
+	canMove := 0
+	if p.OnRoll == you {
+		if plays, err := p.LegalMoves(); err == nil && len(plays) > 0 {
+			canMove = len(plays[0])
+		}
+	}
+
+	ints = append(ints,
+		cube,
+		boolInt(p.CanDouble(you)), boolInt(p.CanDouble(opp)),
+		0, // Was doubled
+		color,
+		-1,          // Direction: towards position 0
+		0, PointBar, // Home and bar positions
+		p.BorneOff(you), p.BorneOff(opp),
+		p.OnBar[you], p.OnBar[opp],
+		canMove,
+		0, // Forced move
+		boolInt(p.Crawford || p.PostCrawford),
+		0, // Redoubles
+	)
+
+	for _, n := range ints {
+		fields = append(fields, strconv.Itoa(n))
+	}
+	return strings.Join(fields, ":")
+}
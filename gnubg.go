@@ -0,0 +1,254 @@
+package bgfparser
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// ToGnuBgID encodes the position as a GNU Backgammon ID, "PositionID:MatchID",
+// the format BGBlitz prints as Position-ID and Match-ID.
+// X is GNU Backgammon's player 1 and O its player 0.
+func (p *Position) ToGnuBgID() string {
+	return p.gnubgPositionID() + ":" + p.gnubgMatchID()
+}
+
+// gnubgPositionID encodes the checkers of the player not on roll, then of
+// the player on roll, each from its own side (points 1-24, then the bar):
+// one bit per checker followed by a zero bit per point, in 80 bits
+func (p *Position) gnubgPositionID() string {
+	onRoll := p.OnRoll
+	if onRoll != "O" {
+		onRoll = "X"
+	}
+
+	var w bitWriter
+	for _, player := range []string{opponent(onRoll), onRoll} {
+		for point := 1; point <= 25; point++ {
+			n := p.OnBar[player]
+			if point < 25 {
+				n = p.checkersAt(player, point)
+			}
+			for i := 0; i < n; i++ {
+				w.write(1, 1)
+			}
+			w.write(0, 1)
+		}
+	}
+	return w.encode(10)
+}
+
+// gnubgMatchID encodes the cube, turn, dice and score fields of the
+// GNU Backgammon match ID in 66 bits, plus the extra bit BGBlitz writes
+func (p *Position) gnubgMatchID() string {
+	cubeLog := 0
+	for v := p.CubeValue; v > 1; v >>= 1 {
+		cubeLog++
+	}
+
+	owner := 3 // Centered
+	if !p.CubeIsCentered() {
+		switch p.CubeOwner {
+		case "X":
+			owner = 1
+		case "O":
+			owner = 0
+		}
+	}
+
+	turn := 1
+	if p.OnRoll == "O" {
+		turn = 0
+	}
+
+	crawford := 0
+	if p.MatchLength > 0 && p.Crawford {
+		crawford = 1
+	}
+
+	var w bitWriter
+	w.write(cubeLog, 4)
+	w.write(owner, 2)
+	w.write(turn, 1) // Player on roll
+	w.write(crawford, 1)
+	w.write(1, 3)    // Game state: playing
+	w.write(turn, 1) // Player to make a decision
+	w.write(0, 1)    // Double offered
+	w.write(0, 2)    // Resignation offered
+	w.write(p.Dice[0], 3)
+	w.write(p.Dice[1], 3)
+	w.write(p.MatchLength, 15)
+	w.write(p.ScoreO, 15)
+	w.write(p.ScoreX, 15)
+	w.write(1, 1) // Always set in the IDs BGBlitz exports
+	return w.encode(9)
+}
+
+// DecodeBGBlitzPositionID decodes a BGBlitz (GNU Backgammon format)
+// Position-ID into the Board and OnBar of a position. The ID is relative to
+// the player on roll, which must be given ("X" or "O").
+func DecodeBGBlitzPositionID(id, onRoll string) (*Position, error) {
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(id, "="))
+	if err != nil || len(data) != 10 {
+		return nil, fmt.Errorf("invalid Position-ID %q", id)
+	}
+	if onRoll != "X" && onRoll != "O" {
+		return nil, fmt.Errorf("invalid player on roll %q", onRoll)
+	}
+
+	pos := &Position{OnRoll: onRoll, OnBar: map[string]int{"X": 0, "O": 0}}
+	r := bitReader{data: data}
+	for _, player := range []string{opponent(onRoll), onRoll} {
+		total := 0
+		for point := 1; point <= 25; point++ {
+			n := 0
+			for r.n < 80 && r.read(1) == 1 {
+				n++
+			}
+			total += n
+			if point == 25 {
+				pos.OnBar[player] = n
+			} else if n > 0 {
+				pos.Board[boardIndex(player, point)] += n * playerSign(player)
+			}
+		}
+		if total > 15 {
+			return nil, fmt.Errorf("invalid Position-ID %q: %d checkers for %s", id, total, player)
+		}
+	}
+	return pos, nil
+}
+
+// MatchState is the match and turn state encoded in a BGBlitz Match-ID
+type MatchState struct {
+	CubeValue     int    `json:"cube_value"`
+	CubeOwner     string `json:"cube_owner"` // "", "X", "O"
+	OnRoll        string `json:"on_roll"`    // "X" or "O"
+	Crawford      bool   `json:"crawford"`
+	DoubleOffered bool   `json:"double_offered"`
+	Dice          Dice   `json:"dice"`
+	MatchLength   int    `json:"match_length"`
+	ScoreX        int    `json:"score_x"`
+	ScoreO        int    `json:"score_o"`
+}
+
+// DecodeBGBlitzMatchID decodes a BGBlitz (GNU Backgammon format) Match-ID,
+// the 12-character base64 string printed next to the Position-ID
+func DecodeBGBlitzMatchID(id string) (MatchState, error) {
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(id, "="))
+	if err != nil || len(data) != 9 {
+		return MatchState{}, fmt.Errorf("invalid Match-ID %q", id)
+	}
+
+	r := bitReader{data: data}
+	state := MatchState{CubeValue: 1 << r.read(4)}
+	switch r.read(2) {
+	case 0:
+		state.CubeOwner = "O"
+	case 1:
+		state.CubeOwner = "X"
+	}
+	state.OnRoll = "O"
+	if r.read(1) == 1 {
+		state.OnRoll = "X"
+	}
+	state.Crawford = r.read(1) == 1
+	r.read(3) // Game state
+	r.read(1) // Player to make a decision
+	state.DoubleOffered = r.read(1) == 1
+	r.read(2) // Resignation offered
+	state.Dice = Dice{r.read(3), r.read(3)}
+	state.MatchLength = r.read(15)
+	state.ScoreO = r.read(15)
+	state.ScoreX = r.read(15)
+
+	if state.Dice[0] > 6 || state.Dice[1] > 6 {
+		return MatchState{}, fmt.Errorf("invalid Match-ID %q: dice %d-%d", id, state.Dice[0], state.Dice[1])
+	}
+	return state, nil
+}
+
+// checkPositionID warns when the Position-ID describes a different board
+// than the XGID, which then takes precedence
+func checkPositionID(pos *Position) {
+	decoded, err := DecodeBGBlitzPositionID(pos.PositionID, pos.OnRoll)
+	if err != nil {
+		pos.Warnings = append(pos.Warnings, err.Error())
+		return
+	}
+	if decoded.Board != pos.Board || decoded.OnBar["X"] != pos.OnBar["X"] || decoded.OnBar["O"] != pos.OnBar["O"] {
+		pos.Warnings = append(pos.Warnings, fmt.Sprintf("Position-ID %s and XGID describe different boards, using the XGID", pos.PositionID))
+	}
+}
+
+// applyMatchID fills the match state of a position from its Match-ID,
+// for files that have no XGID
+func applyMatchID(pos *Position) {
+	state, err := DecodeBGBlitzMatchID(pos.MatchID)
+	if err != nil {
+		pos.Warnings = append(pos.Warnings, err.Error())
+		return
+	}
+
+	pos.CubeValue = state.CubeValue
+	pos.CubeOwner = state.CubeOwner
+	if pos.CubeIsCentered() {
+		pos.CubeOwner = ""
+	}
+	if pos.OnRoll == "" {
+		pos.OnRoll = state.OnRoll
+	}
+	if pos.Dice == (Dice{}) {
+		pos.Dice = state.Dice
+	}
+	if pos.MatchLength == 0 {
+		pos.MatchLength = state.MatchLength
+		pos.ScoreX = state.ScoreX
+		pos.ScoreO = state.ScoreO
+	}
+	pos.Crawford = pos.MatchLength > 0 && state.Crawford
+}
+
+// bitReader reads values packed least significant bit first
+type bitReader struct {
+	data []byte
+	n    int
+}
+
+// read returns the next width bits as a number
+func (r *bitReader) read(width int) int {
+	v := 0
+	for i := 0; i < width; i++ {
+		if r.data[r.n/8]>>(r.n%8)&1 != 0 {
+			v |= 1 << i
+		}
+		r.n++
+	}
+	return v
+}
+
+// bitWriter packs values least significant bit first, as GNU Backgammon IDs do
+type bitWriter struct {
+	data []byte
+	n    int
+}
+
+// write appends the low width bits of v
+func (w *bitWriter) write(v, width int) {
+	for i := 0; i < width; i++ {
+		if w.n%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if v>>i&1 != 0 {
+			w.data[w.n/8] |= 1 << (w.n % 8)
+		}
+		w.n++
+	}
+}
+
+// encode returns the first size bytes in unpadded base64
+func (w *bitWriter) encode(size int) string {
+	data := make([]byte, size)
+	copy(data, w.data)
+	return base64.RawStdEncoding.EncodeToString(data)
+}
@@ -21,30 +21,162 @@ import (
 
 const magic = ":)\n"
 
+var (
+	// ErrInvalidHeader is returned when the data does not start with the SMILE magic header
+	ErrInvalidHeader = errors.New("smile: invalid header")
+
+	// ErrUnsupportedVersion is returned (wrapped with the version number) for
+	// SMILE format versions other than 0
+	ErrUnsupportedVersion = errors.New("smile: unsupported version")
+)
+
 func Unmarshal(data []byte, v interface{}) error {
+	d, err := newDecodeState(data)
+	if err != nil {
+		return err
+	}
+	return d.unmarshal(v)
+}
+
+// UnmarshalWithRecovery decodes like Unmarshal, but when an unknown or
+// unsupported value token is found inside an array or object it records a
+// Warning and resynchronizes at the next structural marker (start/end of an
+// array or object) instead of aborting the whole decode.
+//
+// The structural markers 0xF8-0xFB never occur inside UTF-8 strings or 7-bit
+// encoded numbers, which makes them safe points to resume from.
+func UnmarshalWithRecovery(data []byte, v interface{}) ([]Warning, error) {
+	dec := Decoder{Recover: true}
+	err := dec.Unmarshal(data, v)
+	return dec.Warnings, err
+}
+
+// Decoder decodes SMILE data with optional recovery and diagnostics
+type Decoder struct {
+	// Recover enables recovery mode, see UnmarshalWithRecovery
+	Recover bool
+
+	// PreserveOrder decodes objects as OrderedObject, keeping the key order
+	// of the data, instead of map[string]interface{}
+	PreserveOrder bool
+
+	// RecordOffsets records where the value of each key of the root object
+	// is located in the data. Nothing is recorded when the root is not an object.
+	RecordOffsets bool
+
+	// Warnings lists the tokens skipped in recovery mode
+	Warnings []Warning
+
+	// Offsets maps each top-level key to the [start, end) byte offsets of its
+	// value from the start of the SMILE data (header included)
+	Offsets map[string][2]int
+
+	// SharedResets counts how many times a shared key or value string table
+	// filled up with 1024 strings and was cleared, as the specification requires
+	SharedResets int
+
+	// Truncated is set in recovery mode when the data ended inside an array or
+	// object. The value decoded so far is still stored and io.ErrUnexpectedEOF
+	// is returned.
+	Truncated bool
+
+	// Decoded is the byte offset, from the start of the SMILE data (header
+	// included), just past the last array element or object member decoded
+	// in full. After a truncation, the data past it was lost.
+	Decoded int
+}
+
+// OrderedObject holds the members of an object in encoded order.
+// It is produced instead of map[string]interface{} when PreserveOrder is set.
+type OrderedObject []KeyValue
+
+// KeyValue is a single member of an OrderedObject
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+// Unmarshal decodes data into v, collecting diagnostics into the Decoder
+func (dec *Decoder) Unmarshal(data []byte, v interface{}) error {
+	d, err := newDecodeState(data)
+	if err != nil {
+		return err
+	}
+	d.recovery = dec.Recover
+	d.preserveOrder = dec.PreserveOrder
+	if dec.RecordOffsets {
+		d.offsets = make(map[string][2]int)
+	}
+
+	d.complete = d.offset()
+	err = d.unmarshal(v)
+	if err == nil {
+		d.complete = d.offset()
+	}
+	dec.Decoded = int(d.complete)
+	dec.Warnings = d.warnings
+	dec.Offsets = d.offsets
+	dec.Truncated = d.recovery && err == io.ErrUnexpectedEOF
+	dec.SharedResets = d.sKeys.resets + d.sVals.resets
+	return err
+}
+
+func newDecodeState(data []byte) (*decodeState, error) {
 	if len(data) < 4 || string(data[:len(magic)]) != magic {
-		return errors.New("smile: invalid header")
+		return nil, ErrInvalidHeader
 	}
 
 	h := data[3]
 	if ver := h >> 4; ver != 0 {
-		return fmt.Errorf("smile: unsupported version: %d", ver)
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, ver)
 	}
 
-	d := decodeState{
-		r:          bytes.NewReader(data[4:]),
+	src := bytes.NewReader(data[4:])
+	return &decodeState{
+		r:          src,
+		src:        src,
 		rawBinary:  h&4 != 0,
 		sStringVal: h&2 != 0,
 		sPropName:  h&1 != 0,
 		buf:        make([]byte, 1),
-	}
-	return d.unmarshal(v)
+	}, nil
+}
+
+// Warning describes a value token skipped while decoding in recovery mode
+type Warning struct {
+	Offset  int64 // Offset of the token from the start of the SMILE data
+	Token   byte
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("offset %d: %s", w.Offset, w.Message)
+}
+
+// tokenError is returned for value tokens the decoder cannot handle
+type tokenError struct {
+	token  byte
+	offset int64
+	err    error
+}
+
+func (e *tokenError) Error() string {
+	return e.err.Error()
 }
 
 type decodeState struct {
 	r   io.Reader
+	src *bytes.Reader
 	buf []byte
 
+	recovery      bool
+	preserveOrder bool
+	warnings      []Warning
+
+	depth    int               // Current object and array nesting depth
+	complete int64             // Offset past the last member decoded in full
+	offsets  map[string][2]int // Top-level value offsets, nil when not recorded
+
 	rawBinary  bool
 	sStringVal bool
 	sPropName  bool
@@ -76,6 +208,9 @@ const (
 	longUnicode = 0xe4
 	longSString = 0xec
 
+	binary7Bit   = 0xe8 // 7-bit safe encoded binary
+	rawBinaryTok = 0xfd // Raw binary, only valid when the header enables it
+
 	startArray  = 0xf8
 	endArray    = 0xf9
 	startObject = 0xfa
@@ -108,7 +243,11 @@ func (d *decodeState) value(v reflect.Value) error {
 
 	switch b & 0xe0 {
 	case 0x00:
-		return d.setString(v, d.sVals[b&0x1f-1])
+		s, err := d.sVals.get(int(b&0x1f) - 1)
+		if err != nil {
+			return err
+		}
+		return d.setString(v, s)
 	case 0x20:
 		switch b {
 		case emptyString:
@@ -227,6 +366,12 @@ func (d *decodeState) value(v reflect.Value) error {
 				return err
 			}
 			return d.setString(v, s)
+		case binary7Bit, rawBinaryTok:
+			data, err := d.binary(b)
+			if err != nil {
+				return err
+			}
+			return d.setBytes(v, data)
 		case startArray:
 			return d.array(v)
 		case startObject:
@@ -247,7 +392,11 @@ func (d *decodeState) value(v reflect.Value) error {
 func (d *decodeState) valueInterface(b byte) (interface{}, error) {
 	switch b & 0xe0 {
 	case 0x00:
-		return d.sVals[b&0x1f-1], nil
+		s, err := d.sVals.get(int(b&0x1f) - 1)
+		if err != nil {
+			return nil, d.tokenError(b, err)
+		}
+		return s, nil
 	case 0x20:
 		switch b {
 		case emptyString:
@@ -263,11 +412,19 @@ func (d *decodeState) valueInterface(b byte) (interface{}, error) {
 		case bigInt:
 			return d.bigInt()
 		case float32Tok:
-			return d.float32()
+			n, err := d.float32()
+			if err != nil {
+				return nil, d.tokenError(b, err)
+			}
+			return n, nil
 		case float64Tok:
 			return d.float64()
 		case bigDecimal:
-			return d.bigDecimal()
+			n, err := d.bigDecimal()
+			if err != nil {
+				return nil, d.tokenError(b, err)
+			}
+			return n, nil
 		}
 	case 0x40:
 		return d.stringInterface(b, 1, &d.sVals)
@@ -283,6 +440,8 @@ func (d *decodeState) valueInterface(b byte) (interface{}, error) {
 		switch b {
 		case longAscii, longUnicode:
 			return d.longString()
+		case binary7Bit, rawBinaryTok:
+			return d.binary(b)
 		case startArray:
 			return d.arrayInterface()
 		case startObject:
@@ -293,7 +452,72 @@ func (d *decodeState) valueInterface(b byte) (interface{}, error) {
 			}
 		}
 	}
-	return nil, fmt.Errorf("smile: unexpected value type %x", b)
+	return nil, d.tokenError(b, fmt.Errorf("smile: unexpected value type %x", b))
+}
+
+// offset returns the current read offset from the start of the SMILE data
+func (d *decodeState) offset() int64 {
+	return int64(len(magic)+1) + d.src.Size() - int64(d.src.Len())
+}
+
+// tokenError wraps err for the value token b that was just read
+func (d *decodeState) tokenError(b byte, err error) error {
+	return &tokenError{token: b, offset: d.offset() - 1, err: err}
+}
+
+// recoverValue handles a failed value decode in recovery mode. It records a
+// warning and skips ahead to the next structural marker. If the marker starts
+// an array or object, that container is decoded and returned as the value;
+// if it ends a container, the marker is returned so the caller can close it.
+// Truncation errors are passed through along with the partially decoded val.
+func (d *decodeState) recoverValue(val interface{}, err error) (interface{}, byte, error) {
+	if d.truncated(err) {
+		return val, 0, err
+	}
+
+	tokErr, ok := err.(*tokenError)
+	if !d.recovery || !ok {
+		return nil, 0, err
+	}
+
+	d.warnings = append(d.warnings, Warning{
+		Offset:  tokErr.offset,
+		Token:   tokErr.token,
+		Message: tokErr.Error(),
+	})
+
+	for {
+		b, err := d.ReadByte()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		switch b {
+		case startArray:
+			val, err := d.arrayInterface()
+			return val, 0, err
+		case startObject:
+			val, err := d.objectInterface()
+			return val, 0, err
+		case endArray, endObject:
+			return nil, b, nil
+		}
+	}
+}
+
+// truncated reports whether err means the data ended before the current
+// container was closed. In recovery mode the partially decoded container is kept.
+func (d *decodeState) truncated(err error) bool {
+	return d.recovery && (err == io.EOF || err == io.ErrUnexpectedEOF)
+}
+
+// isContainer reports whether val is a decoded array or object
+func isContainer(val interface{}) bool {
+	switch val.(type) {
+	case []interface{}, map[string]interface{}, OrderedObject:
+		return true
+	}
+	return false
 }
 
 func (d *decodeState) array(v reflect.Value) error {
@@ -302,6 +526,9 @@ func (d *decodeState) array(v reflect.Value) error {
 		if v.NumMethod() == 0 {
 			i, err := d.arrayInterface()
 			if err != nil {
+				if d.truncated(err) && i != nil {
+					v.Set(reflect.ValueOf(i))
+				}
 				return err
 			}
 			v.Set(reflect.ValueOf(i))
@@ -311,10 +538,16 @@ func (d *decodeState) array(v reflect.Value) error {
 }
 
 func (d *decodeState) arrayInterface() ([]interface{}, error) {
+	d.depth++
+	defer func() { d.depth-- }()
+
 	var v = make([]interface{}, 0)
 	for {
 		b, err := d.ReadByte()
 		if err != nil {
+			if d.truncated(err) {
+				return v, io.ErrUnexpectedEOF
+			}
 			return nil, err
 		}
 		if b == endArray {
@@ -323,10 +556,24 @@ func (d *decodeState) arrayInterface() ([]interface{}, error) {
 
 		val, err := d.valueInterface(b)
 		if err != nil {
-			return nil, err
+			var end byte
+			val, end, err = d.recoverValue(val, err)
+			if err != nil {
+				if d.truncated(err) {
+					if isContainer(val) {
+						v = append(v, val)
+					}
+					return v, io.ErrUnexpectedEOF
+				}
+				return nil, err
+			}
+			if end != 0 {
+				return v, nil
+			}
 		}
 
 		v = append(v, val)
+		d.complete = d.offset()
 	}
 }
 
@@ -342,9 +589,9 @@ func (d *decodeState) key(b byte) (string, error) {
 			return "", err
 		}
 		i := int(b&0x03)<<8 | int(b2)
-		return d.sKeys[i], nil
+		return d.sKeys.get(i)
 	case 0x40 <= b && b < 0x80:
-		return d.sKeys[b&0x3f], nil
+		return d.sKeys.get(int(b & 0x3f))
 	case 0x80 <= b && b < 0xc0:
 		return d.stringInterface(b, 1, &d.sKeys)
 	case 0xc0 <= b && b < 0xf8:
@@ -359,6 +606,9 @@ func (d *decodeState) object(v reflect.Value) error {
 		if v.NumMethod() == 0 {
 			i, err := d.objectInterface()
 			if err != nil {
+				if d.truncated(err) && i != nil {
+					v.Set(reflect.ValueOf(i))
+				}
 				return err
 			}
 			v.Set(reflect.ValueOf(i))
@@ -367,33 +617,75 @@ func (d *decodeState) object(v reflect.Value) error {
 	return nil
 }
 
-func (d *decodeState) objectInterface() (map[string]interface{}, error) {
+// objectInterface decodes an object as map[string]interface{}, or as an
+// OrderedObject in PreserveOrder mode
+func (d *decodeState) objectInterface() (interface{}, error) {
+	d.depth++
+	defer func() { d.depth-- }()
+
 	m := make(map[string]interface{})
-	for {
-		b, err := d.ReadByte()
-		if err != nil {
-			return nil, err
+	var keys []string
+	result := func() interface{} {
+		if !d.preserveOrder {
+			return m
 		}
-		if b == endObject {
-			return m, nil
+		obj := make(OrderedObject, 0, len(keys))
+		for _, k := range keys {
+			obj = append(obj, KeyValue{Key: k, Value: m[k]})
+		}
+		return obj
+	}
+	set := func(key string, val interface{}) {
+		if _, ok := m[key]; !ok {
+			keys = append(keys, key)
 		}
+		m[key] = val
+	}
 
-		key, err := d.key(b)
-		if err != nil {
-			return nil, err
+	for {
+		b, err := d.ReadByte()
+		if err == nil && b == endObject {
+			return result(), nil
 		}
 
-		b, err = d.ReadByte()
+		var key string
+		if err == nil {
+			key, err = d.key(b)
+		}
+		if err == nil {
+			b, err = d.ReadByte()
+		}
 		if err != nil {
+			if d.truncated(err) {
+				return result(), io.ErrUnexpectedEOF
+			}
 			return nil, err
 		}
+		start := d.offset() - 1
 
 		val, err := d.valueInterface(b)
 		if err != nil {
-			return nil, err
+			var end byte
+			val, end, err = d.recoverValue(val, err)
+			if err != nil {
+				if d.truncated(err) {
+					if isContainer(val) {
+						set(key, val)
+					}
+					return result(), io.ErrUnexpectedEOF
+				}
+				return nil, err
+			}
+			if end != 0 {
+				return result(), nil
+			}
 		}
 
-		m[key] = val
+		set(key, val)
+		d.complete = d.offset()
+		if d.offsets != nil && d.depth == 1 {
+			d.offsets[key] = [2]int{int(start), int(d.offset())}
+		}
 	}
 }
 
@@ -443,19 +735,72 @@ func (d *decodeState) longString() (string, error) {
 	}
 }
 
+// longSharedString resolves a long shared value reference, token b and an
+// index byte. It indexes the same table as short references: only short
+// strings are shared, so long strings never take an entry.
 func (d *decodeState) longSharedString(b byte) (string, error) {
 	b2, err := d.ReadByte()
 	if err != nil {
 		return "", err
 	}
 	i := int(b&0x03)<<8 | int(b2)
-	return d.sVals[i], nil
+	s, err := d.sVals.get(i)
+	if err != nil {
+		// Report the offset of the token, not of its index byte
+		return "", &tokenError{token: b, offset: d.offset() - 2, err: err}
+	}
+	return s, nil
 }
 
 func (d *decodeState) longKeyString() (string, error) {
 	return "", errors.New("smile: not implemented: long key string")
 }
 
+// setBytes stores a binary value into a []byte, string or empty interface
+func (d *decodeState) setBytes(v reflect.Value, data []byte) error {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(data)
+		}
+	case reflect.String:
+		v.SetString(string(data))
+	case reflect.Interface:
+		if v.NumMethod() == 0 {
+			v.Set(reflect.ValueOf(data))
+		}
+	}
+	return nil
+}
+
+// binary reads the value of a binary token: 7-bit safe encoded data, or raw
+// 8-bit bytes when the header enables raw binary
+func (d *decodeState) binary(b byte) ([]byte, error) {
+	if b == binary7Bit {
+		return d.safeBytes(b)
+	}
+
+	start := d.offset() - 1
+	l, err := d.int(false)
+	if err != nil {
+		return nil, err
+	}
+	if l < 0 || l > int64(d.src.Len()) {
+		return nil, &tokenError{token: b, offset: start, err: fmt.Errorf("smile: invalid binary length %d", l)}
+	}
+	data := make([]byte, l)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		return nil, err
+	}
+
+	// The payload is consumed first so recovery resumes after it rather than
+	// on a marker byte inside the raw data
+	if !d.rawBinary {
+		return nil, d.tokenError(b, errors.New("smile: raw binary value without raw binary header flag"))
+	}
+	return data, nil
+}
+
 func (d *decodeState) setInt(v reflect.Value, n int64) error {
 	switch v.Kind() {
 	case reflect.String:
@@ -500,13 +845,23 @@ func (d *decodeState) int(signed bool) (int64, error) {
 	}
 }
 
-func (d *decodeState) safeBytes() ([]byte, error) {
+// safeBytes reads the 7-bit encoded data of token b, which was just read
+func (d *decodeState) safeBytes(b byte) ([]byte, error) {
+	start := d.offset() - 1
 	l, err := d.int(false)
 	if err != nil {
 		return nil, err
 	}
+	// Each 8 encoded bytes hold 7 data bytes, so a length the remaining
+	// input cannot hold is rejected before allocating
+	if remaining := int64(d.src.Len()); l < 0 || l > (remaining*7+7)/8 {
+		return nil, &tokenError{token: b, offset: start, err: fmt.Errorf("smile: invalid binary length %d", l)}
+	}
 
 	bytes := make([]byte, 0, l)
+	if l == 0 {
+		return bytes, nil
+	}
 	var scratch, scratchL byte
 
 	for {
@@ -538,10 +893,14 @@ func (d *decodeState) safeBytes() ([]byte, error) {
 }
 
 func (d *decodeState) bigInt() (*big.Int, error) {
-	bytes, err := d.safeBytes()
+	start := d.offset() - 1
+	bytes, err := d.safeBytes(bigInt)
 	if err != nil {
 		return nil, err
 	}
+	if len(bytes) == 0 {
+		return nil, &tokenError{token: bigInt, offset: start, err: errors.New("smile: empty big integer")}
+	}
 
 	n := new(big.Int)
 	if bytes[0]&0b10000000 != 0 {
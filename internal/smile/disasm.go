@@ -0,0 +1,182 @@
+package smile
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxListedBytes is the number of raw bytes shown for a single token
+const maxListedBytes = 8
+
+// Disassemble writes a listing of the tokens of SMILE data to w, one line
+// per token with its offset, raw bytes, token type and decoded value.
+// Variable-length tokens (strings, integers, floats, binary) are listed as
+// a whole. Containers are listed as their start and end markers.
+func Disassemble(w io.Writer, data []byte) error {
+	d, err := newDecodeState(data)
+	if err != nil {
+		return err
+	}
+	if err := listToken(w, data, 0, len(magic)+1, "header", headerFlags(data[3])); err != nil {
+		return err
+	}
+
+	type frame struct{ object, expectKey bool }
+	var stack []frame
+	valueDone := func() {
+		if len(stack) > 0 && stack[len(stack)-1].object {
+			stack[len(stack)-1].expectKey = true
+		}
+	}
+	pop := func(object bool) bool {
+		if len(stack) == 0 || stack[len(stack)-1].object != object {
+			return false
+		}
+		stack = stack[:len(stack)-1]
+		valueDone()
+		return true
+	}
+
+	for {
+		start := int(d.offset())
+		b, err := d.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var kind, value string
+		switch {
+		case len(stack) > 0 && stack[len(stack)-1].expectKey && b != endObject:
+			key, err := d.key(b)
+			if err != nil {
+				return fmt.Errorf("offset %d: %w", start, err)
+			}
+			kind, value = "key", strconv.Quote(key)
+			stack[len(stack)-1].expectKey = false
+		case b == startArray:
+			kind = "start array"
+			stack = append(stack, frame{})
+		case b == startObject:
+			kind = "start object"
+			stack = append(stack, frame{object: true, expectKey: true})
+		case b == endArray, b == endObject:
+			kind = "end array"
+			if b == endObject {
+				kind = "end object"
+			}
+			if !pop(b == endObject) {
+				return fmt.Errorf("offset %d: smile: unbalanced %s", start, kind)
+			}
+		default:
+			v, err := d.valueInterface(b)
+			if err != nil {
+				return fmt.Errorf("offset %d: %w", start, err)
+			}
+			kind, value = tokenType(b), formatValue(v)
+			valueDone()
+		}
+
+		if err := listToken(w, data, start, int(d.offset()), kind, value); err != nil {
+			return err
+		}
+	}
+}
+
+// listToken writes the listing line of the token at data[start:end]
+func listToken(w io.Writer, data []byte, start, end int, kind, value string) error {
+	raw := data[start:end]
+	more := ""
+	if len(raw) > maxListedBytes {
+		raw, more = raw[:maxListedBytes], " ..."
+	}
+	hex := fmt.Sprintf("% x", raw) + more
+
+	line := fmt.Sprintf("%6d  %-27s %-14s %s", start, hex, kind, value)
+	_, err := fmt.Fprintln(w, strings.TrimRight(line, " "))
+	return err
+}
+
+// headerFlags describes the version and feature flags of the header byte
+func headerFlags(h byte) string {
+	desc := []string{fmt.Sprintf("version %d", h>>4)}
+	if h&1 != 0 {
+		desc = append(desc, "shared keys")
+	}
+	if h&2 != 0 {
+		desc = append(desc, "shared values")
+	}
+	if h&4 != 0 {
+		desc = append(desc, "raw binary")
+	}
+	return strings.Join(desc, ", ")
+}
+
+// tokenType names the type of value token b
+func tokenType(b byte) string {
+	switch b & 0xe0 {
+	case 0x00:
+		return "shared value"
+	case 0x20:
+		switch b {
+		case emptyString:
+			return "empty string"
+		case null:
+			return "null"
+		case falseTok:
+			return "false"
+		case trueTok:
+			return "true"
+		case int32Tok:
+			return "int32"
+		case int64Tok:
+			return "int64"
+		case bigInt:
+			return "big int"
+		case float32Tok:
+			return "float32"
+		case float64Tok:
+			return "float64"
+		case bigDecimal:
+			return "big decimal"
+		}
+	case 0x40, 0x60:
+		return "short ascii"
+	case 0x80, 0xa0:
+		return "short unicode"
+	case 0xc0:
+		return "small int"
+	case 0xe0:
+		switch b {
+		case longAscii:
+			return "long ascii"
+		case longUnicode:
+			return "long unicode"
+		case binary7Bit:
+			return "binary"
+		case rawBinaryTok:
+			return "raw binary"
+		}
+		if b&0xfc == longSString {
+			return "shared value"
+		}
+	}
+	return "unknown"
+}
+
+// formatValue formats a decoded scalar value for the listing
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case []byte:
+		return fmt.Sprintf("%d bytes", len(v))
+	}
+	return fmt.Sprint(v)
+}
@@ -4,13 +4,33 @@
 
 package smile
 
-type shared []string
+import "fmt"
 
-func (sPtr *shared) add(val string) {
-	s := *sPtr
-	if len(s) >= 1024 {
-		s = s[:0]
+// maxShared is the size of a shared string table. Per the SMILE specification
+// the table is cleared, not evicted entry by entry, once it holds that many strings.
+const maxShared = 1024
+
+// shared is a table of back-referenceable key names or string values
+type shared struct {
+	strings []string
+	resets  int // Number of times the table filled up and was cleared
+}
+
+func (s *shared) add(val string) {
+	if len(s.strings) >= maxShared {
+		// Start a new table rather than reslicing, so that no string
+		// from before the reset can be reached through the backing array
+		s.strings = make([]string, 0, maxShared)
+		s.resets++
+	}
+	s.strings = append(s.strings, val)
+}
+
+// get returns the shared string with index i, failing for references to
+// strings that were never seen instead of panicking
+func (s *shared) get(i int) (string, error) {
+	if i < 0 || i >= len(s.strings) {
+		return "", fmt.Errorf("smile: invalid shared string reference %d (%d shared)", i, len(s.strings))
 	}
-	s = append(s, val)
-	*sPtr = s
+	return s.strings[i], nil
 }
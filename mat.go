@@ -0,0 +1,104 @@
+package bgfparser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// matColumnWidth is the width of the first player's column in .mat move lines
+const matColumnWidth = 28
+
+// ToMAT writes the match in the .mat text format used by Jellyfish and
+// GNU Backgammon: the match length header, then for each game a header with
+// the scores and numbered lines holding one action of each player.
+// Green is written as the first player and Red as the second.
+//
+// Only "amove" checker plays are documented in BGF; other move types are
+// written as cube actions when their name contains "double", "take"/"accept"
+// or "pass"/"drop"/"reject", and skipped otherwise.
+func (m *Match) ToMAT(w io.Writer) error {
+	info := m.GetMatchInfo()
+	green, _ := info["playerGreen"].(string)
+	red, _ := info["playerRed"].(string)
+	matchLength, _ := info["matchLength"].(int)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, " %d point match\n", matchLength)
+
+	games := m.Games()
+	for i, game := range games {
+		fmt.Fprintf(&b, "\n Game %d\n", i+1)
+		fmt.Fprintf(&b, " %-*s %s : %d\n", matColumnWidth+4, fmt.Sprintf("%s : %d", green, game.ScoreGreen), red, game.ScoreRed)
+
+		rows := matRows(game.Moves)
+		for n, row := range rows {
+			line := fmt.Sprintf("%3d) %-*s %s", n+1, matColumnWidth, row[0], row[1])
+			b.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+
+		if points := game.WonPoints; points != 0 {
+			if points < 0 {
+				points = -points
+			}
+			result := fmt.Sprintf("Wins %d point", points)
+			if points > 1 {
+				result += "s"
+			}
+			if gameWinner(games, i, info) > 0 {
+				fmt.Fprintf(&b, "      %s\n", result)
+			} else {
+				fmt.Fprintf(&b, "      %-*s %s\n", matColumnWidth, "", result)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// matRows lays out the actions of a game as numbered lines, Green's action
+// in the first cell and Red's in the second
+func matRows(moves []GameMove) [][2]string {
+	var rows [][2]string
+	cube := 1
+	for _, move := range moves {
+		text, ok := matAction(move, &cube)
+		if !ok {
+			continue
+		}
+
+		col := 0
+		if move.Player < 0 {
+			col = 1
+		}
+		last := len(rows) - 1
+		if last < 0 || rows[last][col] != "" || (col == 0 && rows[last][1] != "") {
+			rows = append(rows, [2]string{})
+			last++
+		}
+		rows[last][col] = text
+	}
+	return rows
+}
+
+// matAction returns the .mat notation of a move, tracking the cube value
+func matAction(move GameMove, cube *int) (string, bool) {
+	kind := strings.ToLower(move.Type)
+	switch {
+	case kind == "amove":
+		var parts []string
+		for _, cm := range move.Moves {
+			parts = append(parts, fmt.Sprintf("%d/%d", cm.From, cm.To))
+		}
+		return strings.TrimSpace(fmt.Sprintf("%d%d: %s", move.Dice[0], move.Dice[1], strings.Join(parts, " "))), true
+	case strings.Contains(kind, "double"):
+		return fmt.Sprintf("Doubles => %d", *cube*2), true
+	case strings.Contains(kind, "take"), strings.Contains(kind, "accept"):
+		*cube *= 2
+		return "Takes", true
+	case strings.Contains(kind, "pass"), strings.Contains(kind, "drop"), strings.Contains(kind, "reject"):
+		return "Drops", true
+	}
+	return "", false
+}
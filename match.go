@@ -0,0 +1,588 @@
+package bgfparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Games returns the typed games of the match decoded from Data.
+// Entries that are not game objects are skipped.
+func (m *Match) Games() []Game {
+	rawGames, _ := m.Data["games"].([]interface{})
+
+	games := make([]Game, 0, len(rawGames))
+	for _, raw := range rawGames {
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		games = append(games, parseGame(obj))
+	}
+
+	return games
+}
+
+// GameSummary is the outcome of a single game of a match
+type GameSummary struct {
+	Number     int    `json:"number"`      // Game number, starting at 1
+	ScoreGreen int    `json:"score_green"` // Green's score at the end of the game
+	ScoreRed   int    `json:"score_red"`   // Red's score at the end of the game
+	WonPoints  int    `json:"won_points"`
+	Winner     string `json:"winner"` // "green" or "red"
+}
+
+// GameCount returns the number of games in the match data. It fails when
+// the data has no "games" array.
+func (m *Match) GameCount() (int, error) {
+	rawGames, err := m.rawGames()
+	if err != nil {
+		return 0, err
+	}
+	return len(rawGames), nil
+}
+
+// GameSummaries returns the number, final score and winner of each game.
+// The winner is taken from the score at the start of the next game, or from
+// the final match score for the last game. It fails when the data has no
+// "games" array or a game is not an object.
+func (m *Match) GameSummaries() ([]GameSummary, error) {
+	rawGames, err := m.rawGames()
+	if err != nil {
+		return nil, err
+	}
+
+	games := make([]Game, len(rawGames))
+	for i, raw := range rawGames {
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("game %d is a %T, not an object", i+1, raw)
+		}
+		games[i] = parseGame(obj)
+	}
+
+	info := m.GetMatchInfo()
+	summaries := make([]GameSummary, len(games))
+	for i, game := range games {
+		points := game.WonPoints
+		if points < 0 {
+			points = -points
+		}
+		summary := GameSummary{
+			Number:     i + 1,
+			ScoreGreen: game.ScoreGreen,
+			ScoreRed:   game.ScoreRed,
+			WonPoints:  points,
+			Winner:     "green",
+		}
+		if gameWinner(games, i, info) > 0 {
+			summary.ScoreGreen += points
+		} else {
+			summary.ScoreRed += points
+			summary.Winner = "red"
+		}
+		summaries[i] = summary
+	}
+
+	return summaries, nil
+}
+
+// rawGames returns the decoded "games" array of the match data
+func (m *Match) rawGames() ([]interface{}, error) {
+	if m.Data == nil {
+		return nil, fmt.Errorf("match has no data")
+	}
+	raw, ok := m.Data["games"]
+	if !ok {
+		return nil, fmt.Errorf("match data has no games")
+	}
+	rawGames, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("match games is a %T, not an array", raw)
+	}
+	return rawGames, nil
+}
+
+// gameWinner returns 1 when Green won game i and -1 when Red did, from the
+// score at the start of the next game (or the final score for the last game).
+// The sign of WonPoints is used when the scores do not tell.
+func gameWinner(games []Game, i int, info map[string]interface{}) int {
+	nextGreen, nextRed := -1, -1
+	if i+1 < len(games) {
+		nextGreen, nextRed = games[i+1].ScoreGreen, games[i+1].ScoreRed
+	} else {
+		g, gok := info["scoreGreen"].(int)
+		r, rok := info["scoreRed"].(int)
+		if gok && rok {
+			nextGreen, nextRed = g, r
+		}
+	}
+
+	switch {
+	case nextGreen > games[i].ScoreGreen:
+		return 1
+	case nextRed > games[i].ScoreRed:
+		return -1
+	case games[i].WonPoints < 0:
+		return -1
+	}
+	return 1
+}
+
+// Player is a player of a match, normalized from the writer's data layout
+type Player struct {
+	Name   string  `json:"name"`
+	Rating float64 `json:"rating,omitempty"` // 0 when not recorded
+	Color  string  `json:"color"`            // "green" or "red"
+}
+
+// playerFields lists, for each color, the data paths where BGBlitz and other
+// writers store the player's name and rating, in order of preference. The
+// name paths are those GetMatchInfo reads.
+var playerFields = []struct {
+	color  string
+	name   []string
+	rating []string
+}{
+	{"green", matchInfoPaths("playerGreen"),
+		[]string{"ratingGreen", "eloGreen", "players.0.rating", "players.0.elo"}},
+	{"red", matchInfoPaths("playerRed"),
+		[]string{"ratingRed", "eloRed", "players.1.rating", "players.1.elo"}},
+}
+
+// matchInfoPaths returns the data paths of a GetMatchInfo key
+func matchInfoPaths(key string) []string {
+	for _, field := range matchInfoFields {
+		if field.key == key {
+			return field.paths
+		}
+	}
+	return nil
+}
+
+// Players returns the players of the match, Green first. A player is listed
+// when their name or rating is found, so a match recording a single player
+// returns one entry. It fails when no player is found.
+func (m *Match) Players() ([]Player, error) {
+	if m.Data == nil {
+		return nil, fmt.Errorf("match has no data")
+	}
+
+	var players []Player
+	for _, field := range playerFields {
+		name, hasName := m.lookupFirst(field.name)
+		rating, hasRating := m.lookupFirst(field.rating)
+		if !hasName && !hasRating {
+			continue
+		}
+		players = append(players, Player{
+			Name:   strings.TrimSpace(stringValue(name)),
+			Rating: floatValue(rating),
+			Color:  field.color,
+		})
+	}
+
+	if len(players) == 0 {
+		return nil, fmt.Errorf("no players in match data")
+	}
+	return players, nil
+}
+
+// annotationKeys lists the keys under which writers store comment text on
+// a game or move object
+var annotationKeys = []string{"comment", "annotation", "note"}
+
+// Annotations returns the comment text stored on games and moves, keyed by
+// the game index ("0") or the game and move indexes ("0/3"), which index
+// Games() and Game.Moves like AttachAnalysis. Several comments on the same
+// object are joined with newlines. A match without comments returns an
+// empty map. It fails only when the match has no data.
+func (m *Match) Annotations() (map[string]string, error) {
+	if m.Data == nil {
+		return nil, fmt.Errorf("match has no data")
+	}
+
+	annotations := make(map[string]string)
+	rawGames, _ := m.Data["games"].([]interface{})
+	for g, game := range objects(rawGames) {
+		if text := annotationText(game); text != "" {
+			annotations[strconv.Itoa(g)] = text
+		}
+		rawMoves, _ := game["moves"].([]interface{})
+		for i, move := range objects(rawMoves) {
+			if text := annotationText(move); text != "" {
+				annotations[fmt.Sprintf("%d/%d", g, i)] = text
+			}
+		}
+	}
+	return annotations, nil
+}
+
+// annotationText returns the non-blank comments of a decoded object joined
+// with newlines
+func annotationText(obj map[string]interface{}) string {
+	var texts []string
+	for _, key := range annotationKeys {
+		if text := strings.TrimSpace(stringValue(obj[key])); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// engineSettingsPaths lists where BGBlitz versions store the analysis
+// engine settings, tried in order
+var engineSettingsPaths = []string{
+	"engineSettings", "analysisSettings", "evalSettings", "settings",
+	"engine.settings", "analysis.settings",
+}
+
+// engineSettingAliases lists the lowercased spellings of the known
+// settings by normalized name, in priority order: when a match stores
+// several spellings of a setting, the first one listed wins
+var engineSettingAliases = []struct {
+	name    string
+	aliases []string
+}{
+	{"plies", []string{"plies", "ply", "depth", "evalplies"}},
+	{"noise", []string{"noise"}},
+	{"cubeful", []string{"cubeful", "usecube", "cubeuse", "cube"}},
+}
+
+// EngineSettings returns the analysis engine settings stored in the match.
+// The known settings are renamed and converted: "plies" (int), "noise"
+// (float64) and "cubeful" (bool); other keys are kept as decoded. A match
+// without settings returns an empty map. It fails only when the match has
+// no data.
+func (m *Match) EngineSettings() (map[string]interface{}, error) {
+	if m.Data == nil {
+		return nil, fmt.Errorf("match has no data")
+	}
+
+	value, _ := m.lookupFirst(engineSettingsPaths)
+	raw, _ := value.(map[string]interface{})
+
+	// Sorted keys resolve spellings differing only in case the same way
+	// whatever the map order
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	settings := make(map[string]interface{})
+	known := make(map[string]bool)
+	for _, setting := range engineSettingAliases {
+		for _, alias := range setting.aliases {
+			for _, key := range keys {
+				if strings.ToLower(key) != alias {
+					continue
+				}
+				known[key] = true
+				if _, seen := settings[setting.name]; seen {
+					continue
+				}
+				switch setting.name {
+				case "plies":
+					settings[setting.name] = intValue(raw[key])
+				case "noise":
+					settings[setting.name] = floatValue(raw[key])
+				case "cubeful":
+					settings[setting.name] = boolValue(raw[key])
+				}
+			}
+		}
+	}
+	for _, key := range keys {
+		if !known[key] {
+			settings[key] = raw[key]
+		}
+	}
+	return settings, nil
+}
+
+// parseGame converts a decoded game object into a Game
+func parseGame(obj map[string]interface{}) Game {
+	game := Game{
+		ScoreGreen:  intValue(obj["scoreGreen"]),
+		ScoreRed:    intValue(obj["scoreRed"]),
+		WonPoints:   intValue(obj["wonPoints"]),
+		Forfeit:     boolValue(obj["wasForfeit"]),
+		Resignation: boolValue(obj["wasResignation"]),
+		Moves:       []GameMove{},
+	}
+
+	rawMoves, _ := obj["moves"].([]interface{})
+	for _, raw := range rawMoves {
+		if moveObj, ok := raw.(map[string]interface{}); ok {
+			game.Moves = append(game.Moves, parseGameMove(moveObj))
+		}
+	}
+
+	return game
+}
+
+// parseGameMove converts a decoded move object into a GameMove.
+// Checker moves are stored as parallel "from"/"to" arrays padded with -1.
+func parseGameMove(obj map[string]interface{}) GameMove {
+	move := GameMove{
+		Type:   stringValue(obj["type"]),
+		Player: intValue(obj["player"]),
+		Dice:   [2]int{intValue(obj["red"]), intValue(obj["green"])},
+	}
+
+	from, _ := obj["from"].([]interface{})
+	to, _ := obj["to"].([]interface{})
+	for i := 0; i < len(from) && i < len(to); i++ {
+		f, t := intValue(from[i]), intValue(to[i])
+		if f < 0 || t < 0 {
+			continue
+		}
+		move.Moves = append(move.Moves, CheckerMove{From: f, To: t})
+	}
+
+	if equity, ok := obj["equity"].(map[string]interface{}); ok {
+		move.Equity = floatValue(equity["equity"])
+		move.MatchEquity = floatValue(equity["matchEquity"])
+	}
+
+	if raw, ok := obj[analysisKey]; ok {
+		var analysis MoveAnalysis
+		if data, err := json.Marshal(raw); err == nil && json.Unmarshal(data, &analysis) == nil {
+			move.Analysis = &analysis
+		}
+	}
+
+	return move
+}
+
+// analysisKey is the move object key holding an attached MoveAnalysis
+const analysisKey = "analysis"
+
+// AttachAnalysis attaches the evaluations and cube decisions of a position
+// (typically parsed from a TXT export) to a move of the match. gameIdx and
+// moveIdx index Games() and Game.Moves. The analysis is stored in Data (and
+// OrderedData when keys are preserved), so it is kept by serialization and
+// returned as GameMove.Analysis.
+func (m *Match) AttachAnalysis(gameIdx, moveIdx int, pos *Position) error {
+	if pos == nil {
+		return fmt.Errorf("cannot attach analysis: no position")
+	}
+
+	moveObj, err := m.rawMove(gameIdx, moveIdx)
+	if err != nil {
+		return err
+	}
+
+	analysis := MoveAnalysis{Evaluations: pos.Evaluations, CubeDecisions: pos.CubeDecisions}
+	data, err := json.Marshal(analysis)
+	if err != nil {
+		return fmt.Errorf("cannot attach analysis: %v", err)
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("cannot attach analysis: %v", err)
+	}
+
+	moveObj[analysisKey] = value
+	if ordered := m.orderedMove(gameIdx, moveIdx); ordered != nil {
+		ordered.set(analysisKey, value)
+	}
+	return nil
+}
+
+// orderedMove returns the OrderedData move object matching rawMove, or nil
+// when keys were not preserved
+func (m *Match) orderedMove(gameIdx, moveIdx int) *OrderedMap {
+	if m.OrderedData == nil {
+		return nil
+	}
+	rawGames, _ := m.OrderedData.Values["games"].([]interface{})
+	games := orderedObjects(rawGames)
+	if gameIdx >= len(games) {
+		return nil
+	}
+	rawMoves, _ := games[gameIdx].Values["moves"].([]interface{})
+	moves := orderedObjects(rawMoves)
+	if moveIdx >= len(moves) {
+		return nil
+	}
+	return moves[moveIdx]
+}
+
+// rawMove returns the decoded move object matching Games()[gameIdx].Moves[moveIdx]
+func (m *Match) rawMove(gameIdx, moveIdx int) (map[string]interface{}, error) {
+	rawGames, _ := m.Data["games"].([]interface{})
+	games := objects(rawGames)
+	if gameIdx < 0 || gameIdx >= len(games) {
+		return nil, fmt.Errorf("game index %d out of range (%d games)", gameIdx, len(games))
+	}
+
+	rawMoves, _ := games[gameIdx]["moves"].([]interface{})
+	moves := objects(rawMoves)
+	if moveIdx < 0 || moveIdx >= len(moves) {
+		return nil, fmt.Errorf("move index %d out of range (%d moves in game %d)", moveIdx, len(moves), gameIdx)
+	}
+
+	return moves[moveIdx], nil
+}
+
+// objects returns the object entries of a decoded array, skipping other values
+// like Games and parseGame do
+func objects(values []interface{}) []map[string]interface{} {
+	var objs []map[string]interface{}
+	for _, v := range values {
+		if obj, ok := v.(map[string]interface{}); ok {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+// GetString returns the string at a dotted path into Data (e.g. "games.0.date").
+// ok is false if the path is missing or the value is not a string.
+func (m *Match) GetString(path string) (string, bool) {
+	v, ok := m.lookup(path)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+// GetInt returns the integer at a dotted path into Data.
+// ok is false if the path is missing or the value is not a whole number.
+func (m *Match) GetInt(path string) (int, bool) {
+	v, ok := m.lookup(path)
+	if !ok {
+		return 0, false
+	}
+	switch n := v.(type) {
+	case int64, int:
+		return intValue(n), true
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
+
+// GetFloat returns the number at a dotted path into Data.
+// ok is false if the path is missing or the value is not numeric.
+func (m *Match) GetFloat(path string) (float64, bool) {
+	v, ok := m.lookup(path)
+	if !ok {
+		return 0, false
+	}
+	switch v.(type) {
+	case float64, float32, int64, int:
+		return floatValue(v), true
+	}
+	return 0, false
+}
+
+// GetSlice returns the array at a dotted path into Data.
+// ok is false if the path is missing or the value is not an array.
+func (m *Match) GetSlice(path string) ([]interface{}, bool) {
+	v, ok := m.lookup(path)
+	if !ok {
+		return nil, false
+	}
+	s, ok := v.([]interface{})
+	return s, ok
+}
+
+// lookup resolves a dotted path into Data. Path segments are object keys,
+// or array indices when the current value is an array.
+func (m *Match) lookup(path string) (interface{}, bool) {
+	return m.lookupPath(path, false)
+}
+
+// lookupPath resolves a dotted path into Data, optionally matching object
+// keys case-insensitively when no exact key exists
+func (m *Match) lookupPath(path string, foldCase bool) (interface{}, bool) {
+	if m.Data == nil || path == "" {
+		return nil, false
+	}
+
+	var current interface{} = m.Data
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			v, ok := node[segment]
+			if !ok && foldCase {
+				v, ok = foldKey(node, segment)
+			}
+			if !ok {
+				return nil, false
+			}
+			current = v
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			current = node[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// foldKey returns the value of the first key in node equal to key under case folding
+func foldKey(node map[string]interface{}, key string) (interface{}, bool) {
+	for k, v := range node {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// intValue converts a decoded numeric value to int (0 if not numeric).
+// SMILE decodes integers as int64 while plain JSON yields float64.
+func intValue(v interface{}) int {
+	switch n := v.(type) {
+	case int64:
+		return int(n)
+	case int:
+		return n
+	case float64:
+		return int(n)
+	case float32:
+		return int(n)
+	}
+	return 0
+}
+
+// floatValue converts a decoded numeric value to float64 (0 if not numeric)
+func floatValue(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case int:
+		return float64(n)
+	}
+	return 0
+}
+
+// stringValue returns v if it is a string, otherwise ""
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// boolValue returns v if it is a bool, otherwise false
+func boolValue(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
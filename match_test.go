@@ -0,0 +1,637 @@
+package bgfparser_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/kevung/bgfparser"
+)
+
+// parseJSONMatch parses an uncompressed JSON BGF payload
+func parseJSONMatch(t *testing.T, data string) *bgfparser.Match {
+	t.Helper()
+	header := `{"format":"BGF","version":"1.0","compress":false,"useSmile":false}` + "\n"
+	match, err := bgfparser.ParseBGFFromReader(strings.NewReader(header + data))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	return match
+}
+
+func TestMatch_TypedAccessors(t *testing.T) {
+	match := parseJSONMatch(t, `{"matchlen":5,"nameGreen":"Alice","players":[{"name":"Alice"},{"name":"Bob"}],`+
+		`"games":[{"moves":[{"equity":{"equity":-0.25}}]}]}`)
+
+	if s, ok := match.GetString("players.1.name"); !ok || s != "Bob" {
+		t.Errorf("GetString(players.1.name) = %q, %v; want Bob, true", s, ok)
+	}
+	if n, ok := match.GetInt("matchlen"); !ok || n != 5 {
+		t.Errorf("GetInt(matchlen) = %d, %v; want 5, true", n, ok)
+	}
+	if f, ok := match.GetFloat("games.0.moves.0.equity.equity"); !ok || f != -0.25 {
+		t.Errorf("GetFloat = %v, %v; want -0.25, true", f, ok)
+	}
+	if s, ok := match.GetSlice("players"); !ok || len(s) != 2 {
+		t.Errorf("GetSlice(players) = %v, %v; want 2 entries", s, ok)
+	}
+
+	// Missing, out-of-range and mismatched paths
+	if _, ok := match.GetString("players.2.name"); ok {
+		t.Error("GetString with out-of-range index should fail")
+	}
+	if _, ok := match.GetString("missing.key"); ok {
+		t.Error("GetString with missing key should fail")
+	}
+	if _, ok := match.GetInt("nameGreen"); ok {
+		t.Error("GetInt on a string should fail")
+	}
+	if _, ok := match.GetInt("games.0.moves.0.equity.equity"); ok {
+		t.Error("GetInt on a fractional number should fail")
+	}
+	if _, ok := match.GetSlice("matchlen.0"); ok {
+		t.Error("GetSlice through a number should fail")
+	}
+}
+
+func TestMatch_GetMatchInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"BGBlitz keys", `{"nameGreen":"Alice","nameRed":"Bob","matchlen":7,"finalGreen":7,"finalRed":4}`},
+		{"Mixed case keys", `{"NameGreen":"Alice","NAMERED":"Bob","MatchLen":7.0,"FinalGreen":7,"finalred":4}`},
+		{"Nested players", `{"players":[{"name":"Alice"},{"name":"Bob"}],"matchLength":7,"scoreGreen":7,"scoreRed":4}`},
+		{"X and O keys", `{"playerX":"Bob","playerO":"Alice","matchLength":7,"scoreGreen":7,"scoreRed":4}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := parseJSONMatch(t, tt.data).GetMatchInfo()
+
+			if info["playerGreen"] != "Alice" || info["playerRed"] != "Bob" {
+				t.Errorf("Players = %v / %v, want Alice / Bob", info["playerGreen"], info["playerRed"])
+			}
+			if info["matchLength"] != 7 {
+				t.Errorf("matchLength = %v, want 7", info["matchLength"])
+			}
+			if info["scoreGreen"] != 7 || info["scoreRed"] != 4 {
+				t.Errorf("Scores = %v / %v, want 7 / 4", info["scoreGreen"], info["scoreRed"])
+			}
+			if _, ok := info["event"]; ok {
+				t.Error("Absent event should not be present")
+			}
+		})
+	}
+
+	// The legacy keys are still returned as found in the data
+	info := parseJSONMatch(t, `{"playerX":"Bob","playerO":"Alice","score":"7-4"}`).GetMatchInfo()
+	if info["playerX"] != "Bob" || info["playerO"] != "Alice" || info["score"] != "7-4" {
+		t.Errorf("Legacy keys = %v / %v / %v, want Bob / Alice / 7-4", info["playerX"], info["playerO"], info["score"])
+	}
+}
+
+func TestMatch_MatchInfoOrdered(t *testing.T) {
+	match := parseJSONMatch(t, `{"nameGreen":"Alice","nameRed":"Bob","matchlen":7,"event":"Club night"}`)
+	info := match.MatchInfoOrdered()
+
+	var keys []string
+	for _, kv := range info {
+		keys = append(keys, kv.Key)
+	}
+	want := []string{"format", "version", "compress", "useSmile", "event", "matchLength", "playerGreen", "playerRed"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("Keys = %v, want %v", keys, want)
+	}
+	if info[0].Value != "BGF" || info[6].Value != "Alice" {
+		t.Errorf("Values = %v / %v, want BGF / Alice", info[0].Value, info[6].Value)
+	}
+
+	// The entries match the map version
+	if len(info) != len(match.GetMatchInfo()) {
+		t.Errorf("len = %d, want %d", len(info), len(match.GetMatchInfo()))
+	}
+}
+
+func TestMatch_ToCanonicalJSON(t *testing.T) {
+	f, err := os.Open("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	match, err := bgfparser.ParseBGFFromReader(f)
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	match.DecodingWarnings = []string{"diagnostic"}
+	match.Data["_debug"] = "internal"
+
+	first, err := match.ToCanonicalJSON()
+	if err != nil {
+		t.Fatalf("ToCanonicalJSON failed: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		again, err := match.ToCanonicalJSON()
+		if err != nil {
+			t.Fatalf("ToCanonicalJSON failed: %v", err)
+		}
+		if !bytes.Equal(first, again) {
+			t.Fatalf("Output differs between runs:\n%s\n%s", first, again)
+		}
+	}
+
+	out := string(first)
+	if strings.Contains(out, "diagnostic") || strings.Contains(out, "_debug") {
+		t.Errorf("Canonical JSON contains diagnostics: %s", out)
+	}
+	if !strings.HasPrefix(out, `{"compress":true,"data":{"games":[{"moves":[{"green":1,"player":1,"red":1,"type":"amove"}`) {
+		t.Errorf("Keys are not sorted: %.120s", out)
+	}
+}
+
+func TestMatch_ToCanonicalJSONNumbers(t *testing.T) {
+	// SMILE yields int64 and JSON yields float64 for the same values
+	match := parseJSONMatch(t, `{"a":3,"b":3.0,"c":0.25,"d":-1e2}`)
+
+	out, err := match.ToCanonicalJSON()
+	if err != nil {
+		t.Fatalf("ToCanonicalJSON failed: %v", err)
+	}
+	if !strings.Contains(string(out), `"data":{"a":3,"b":3,"c":0.25,"d":-100}`) {
+		t.Errorf("Unexpected number formatting: %s", out)
+	}
+}
+
+func TestMatch_AttachAnalysis(t *testing.T) {
+	match, err := bgfparser.ParseBGF("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("ParseBGF failed: %v", err)
+	}
+	pos, err := bgfparser.ParseTXT("test/2025-11-04/03_DT_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	if err := match.AttachAnalysis(1, 2, pos); err != nil {
+		t.Fatalf("AttachAnalysis failed: %v", err)
+	}
+
+	move := match.Games()[1].Moves[2]
+	if move.Analysis == nil {
+		t.Fatal("Analysis not attached")
+	}
+	if !reflect.DeepEqual(move.Analysis.CubeDecisions, pos.CubeDecisions) {
+		t.Errorf("CubeDecisions = %+v, want %+v", move.Analysis.CubeDecisions, pos.CubeDecisions)
+	}
+	if len(move.Analysis.Evaluations) != len(pos.Evaluations) {
+		t.Errorf("Got %d evaluations, want %d", len(move.Analysis.Evaluations), len(pos.Evaluations))
+	}
+	if other := match.Games()[1].Moves[1]; other.Analysis != nil {
+		t.Error("Analysis attached to the wrong move")
+	}
+
+	// The analysis is part of the serialized data
+	jsonData, err := match.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	if !strings.Contains(string(jsonData), `"cube_decisions"`) {
+		t.Error("Serialized match does not contain the attached analysis")
+	}
+
+	// With preserved key order ToJSON serializes OrderedData, which gets
+	// the analysis too
+	f, err := os.Open("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+	ordered, err := bgfparser.ParseBGFFromReaderWithOptions(f, bgfparser.BGFOptions{PreserveKeyOrder: true})
+	if err != nil {
+		t.Fatalf("ParseBGFFromReaderWithOptions failed: %v", err)
+	}
+	if err := ordered.AttachAnalysis(1, 2, pos); err != nil {
+		t.Fatalf("AttachAnalysis failed: %v", err)
+	}
+	if jsonData, err = ordered.ToJSON(); err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	if !strings.Contains(string(jsonData), `"cube_decisions"`) {
+		t.Error("Serialized ordered match does not contain the attached analysis")
+	}
+
+	for _, idx := range [][2]int{{6, 0}, {-1, 0}, {0, 12}, {0, -1}} {
+		if err := match.AttachAnalysis(idx[0], idx[1], pos); err == nil {
+			t.Errorf("AttachAnalysis(%d, %d) expected error", idx[0], idx[1])
+		}
+	}
+}
+
+// matActionRe matches a single action in a .mat move line
+var matActionRe = regexp.MustCompile(`\d\d:|Doubles => \d+|Takes|Drops`)
+
+// matMoveLineRe matches the move number starting a .mat move line
+var matMoveLineRe = regexp.MustCompile(`^\d+\)`)
+
+func TestMatch_ToMAT(t *testing.T) {
+	match, err := bgfparser.ParseBGF("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("ParseBGF failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := match.ToMAT(&buf); err != nil {
+		t.Fatalf("ToMAT failed: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, " 7 point match\n") {
+		t.Errorf("Missing match length header:\n%s", out)
+	}
+
+	// Parse the move lines back, counting games and actions
+	games, actions := 0, 0
+	for _, line := range strings.Split(out, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "Game "):
+			games++
+		case matMoveLineRe.MatchString(trimmed):
+			actions += len(matActionRe.FindAllString(trimmed, -1))
+		}
+	}
+
+	wantActions := 0
+	for _, game := range match.Games() {
+		wantActions += len(game.Moves)
+	}
+	if games != len(match.Games()) {
+		t.Errorf("Got %d games, want %d", games, len(match.Games()))
+	}
+	if actions != wantActions {
+		t.Errorf("Got %d actions, want %d:\n%s", actions, wantActions, out)
+	}
+	if !strings.Contains(out, " Alice : 0") || !strings.Contains(out, "Bob : 0") {
+		t.Errorf("Missing game header scores:\n%s", out)
+	}
+}
+
+func TestMatch_ToMATCubeActions(t *testing.T) {
+	data := `{"nameGreen":"Alice","nameRed":"Bob","matchlen":5,"games":[{"scoreGreen":0,"scoreRed":0,"wonPoints":2,"moves":[` +
+		`{"type":"amove","player":1,"red":3,"green":1,"from":[8,6,-1,-1],"to":[5,5,-1,-1]},` +
+		`{"type":"amove","player":-1,"red":6,"green":4,"from":[24,13,-1,-1],"to":[18,9,-1,-1]},` +
+		`{"type":"double","player":1},{"type":"take","player":-1},` +
+		`{"type":"amove","player":1,"red":2,"green":2,"from":[],"to":[]}]}]}`
+	match, err := bgfparser.ParseBGFFromReader(strings.NewReader(
+		`{"format":"BGF","version":"1.0","compress":false,"useSmile":false}` + "\n" + data))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := match.ToMAT(&buf); err != nil {
+		t.Fatalf("ToMAT failed: %v", err)
+	}
+
+	want := ` 5 point match
+
+ Game 1
+ Alice : 0                        Bob : 0
+  1) 31: 8/5 6/5                  64: 24/18 13/9
+  2) Doubles => 2                 Takes
+  3) 22:
+      Wins 2 points
+`
+	if got := buf.String(); got != want {
+		t.Errorf("ToMAT() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMatch_GameSummaries(t *testing.T) {
+	match, err := bgfparser.ParseBGF("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("ParseBGF failed: %v", err)
+	}
+
+	count, err := match.GameCount()
+	if err != nil {
+		t.Fatalf("GameCount failed: %v", err)
+	}
+	if count != 6 {
+		t.Errorf("GameCount = %d, want 6", count)
+	}
+
+	summaries, err := match.GameSummaries()
+	if err != nil {
+		t.Fatalf("GameSummaries failed: %v", err)
+	}
+	if len(summaries) != count {
+		t.Fatalf("Got %d summaries, want %d", len(summaries), count)
+	}
+	want := bgfparser.GameSummary{Number: 1, ScoreGreen: 1, ScoreRed: 0, WonPoints: 1, Winner: "green"}
+	if summaries[0] != want {
+		t.Errorf("First game = %+v, want %+v", summaries[0], want)
+	}
+	if last := summaries[count-1]; last.Number != 6 || last.ScoreGreen != 6 {
+		t.Errorf("Last game = %+v, want game 6 ending with Green on 6", last)
+	}
+}
+
+func TestMatch_GameSummariesMissingGames(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want string
+	}{
+		{"No data", nil, "no data"},
+		{"No games", map[string]interface{}{"matchlen": 7}, "no games"},
+		{"Games not an array", map[string]interface{}{"games": "none"}, "not an array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := &bgfparser.Match{Data: tt.data}
+			if _, err := match.GameCount(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("GameCount error = %v, want %q", err, tt.want)
+			}
+			if _, err := match.GameSummaries(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("GameSummaries error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	match := &bgfparser.Match{Data: map[string]interface{}{"games": []interface{}{"game"}}}
+	if _, err := match.GameSummaries(); err == nil || !strings.Contains(err.Error(), "game 1") {
+		t.Errorf("GameSummaries error = %v, want game 1 rejected", err)
+	}
+}
+
+func TestMatch_Players(t *testing.T) {
+	match, err := bgfparser.ParseBGF("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("ParseBGF failed: %v", err)
+	}
+	players, err := match.Players()
+	if err != nil {
+		t.Fatalf("Players failed: %v", err)
+	}
+	want := []bgfparser.Player{{Name: "Alice", Color: "green"}, {Name: "Bob", Color: "red"}}
+	if !reflect.DeepEqual(players, want) {
+		t.Errorf("Players = %+v, want %+v", players, want)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want []bgfparser.Player
+	}{
+		{"Players array", `{"players":[{"name":"Alice","rating":1650.5},{"name":"Bob","elo":1580}]}`,
+			[]bgfparser.Player{{Name: "Alice", Rating: 1650.5, Color: "green"}, {Name: "Bob", Rating: 1580, Color: "red"}}},
+		{"Alternate keys", `{"PlayerO":"Alice","ratingRed":1500}`,
+			[]bgfparser.Player{{Name: "Alice", Color: "green"}, {Rating: 1500, Color: "red"}}},
+		{"X is Red", `{"playerX":"Bob","playerO":"Alice"}`,
+			[]bgfparser.Player{{Name: "Alice", Color: "green"}, {Name: "Bob", Color: "red"}}},
+		{"Single player", `{"nameRed":"Bob"}`,
+			[]bgfparser.Player{{Name: "Bob", Color: "red"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			players, err := parseJSONMatch(t, tt.data).Players()
+			if err != nil {
+				t.Fatalf("Players failed: %v", err)
+			}
+			if !reflect.DeepEqual(players, tt.want) {
+				t.Errorf("Players = %+v, want %+v", players, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseJSONMatch(t, `{"matchlen":7}`).Players(); err == nil {
+		t.Error("Players without any player should fail")
+	}
+	if _, err := (&bgfparser.Match{}).Players(); err == nil {
+		t.Error("Players without data should fail")
+	}
+}
+
+func TestMatch_Annotations(t *testing.T) {
+	match, err := bgfparser.ParseBGF("test/fixtures/annotated_smile.bgf")
+	if err != nil {
+		t.Fatalf("ParseBGF failed: %v", err)
+	}
+	annotations, err := match.Annotations()
+	if err != nil {
+		t.Fatalf("Annotations failed: %v", err)
+	}
+	// The blank comment on the double is skipped
+	want := map[string]string{
+		"0/1": "Bold play, splits the back",
+		"0/3": "Close take",
+		"1":   "Gammonish opening",
+	}
+	if !reflect.DeepEqual(annotations, want) {
+		t.Errorf("Annotations = %q, want %q", annotations, want)
+	}
+
+	// Keys index Games() and Game.Moves
+	if got := match.Games()[0].Moves[3].Type; got != "take" {
+		t.Errorf("Move 0/3 type = %q, want take", got)
+	}
+
+	match, err = bgfparser.ParseBGF("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("ParseBGF failed: %v", err)
+	}
+	annotations, err = match.Annotations()
+	if err != nil || annotations == nil || len(annotations) != 0 {
+		t.Errorf("Annotations without comments = %v, %v, want an empty map", annotations, err)
+	}
+	if _, err := (&bgfparser.Match{}).Annotations(); err == nil {
+		t.Error("Annotations without data should fail")
+	}
+}
+
+func TestMatch_EngineSettings(t *testing.T) {
+	match, err := bgfparser.ParseBGF("test/fixtures/engine_settings_smile.bgf")
+	if err != nil {
+		t.Fatalf("ParseBGF failed: %v", err)
+	}
+	settings, err := match.EngineSettings()
+	if err != nil {
+		t.Fatalf("EngineSettings failed: %v", err)
+	}
+	// "ply" and "useCube" are normalized, "engine" is kept as is
+	want := map[string]interface{}{
+		"plies":   3,
+		"noise":   0.025,
+		"cubeful": true,
+		"engine":  "BGBlitz",
+	}
+	if !reflect.DeepEqual(settings, want) {
+		t.Errorf("EngineSettings = %v, want %v", settings, want)
+	}
+
+	match = parseJSONMatch(t, `{"analysisSettings": {"Plies": 2, "depth": 4, "noise": 0}}`)
+	settings, err = match.EngineSettings()
+	if err != nil {
+		t.Fatalf("EngineSettings failed: %v", err)
+	}
+	if settings["plies"] != 2 {
+		t.Errorf("plies = %v, want 2 from the exact key", settings["plies"])
+	}
+
+	// Aliases resolve in a fixed order, whatever the map order
+	for i := 0; i < 20; i++ {
+		match = parseJSONMatch(t, `{"settings": {"depth": 4, "ply": 3, "cube": false, "useCube": true}}`)
+		settings, err = match.EngineSettings()
+		if err != nil {
+			t.Fatalf("EngineSettings failed: %v", err)
+		}
+		if settings["plies"] != 3 || settings["cubeful"] != true || len(settings) != 2 {
+			t.Fatalf("EngineSettings = %v, want plies 3 from ply and cubeful from useCube", settings)
+		}
+	}
+
+	match, err = bgfparser.ParseBGF("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("ParseBGF failed: %v", err)
+	}
+	settings, err = match.EngineSettings()
+	if err != nil || settings == nil || len(settings) != 0 {
+		t.Errorf("EngineSettings without settings = %v, %v, want an empty map", settings, err)
+	}
+	if _, err := (&bgfparser.Match{}).EngineSettings(); err == nil {
+		t.Error("EngineSettings without data should fail")
+	}
+}
+
+// playGame plays a game from the opening position, each player taking the
+// first legal play with the given rolls in turn, Green (O) first. It returns
+// the BGF move objects, the final board and the winning BGF player.
+func playGame(t *testing.T, rolls [][2]int) ([]map[string]interface{}, [26]int, int) {
+	t.Helper()
+	board := [26]int{0, -2, 0, 0, 0, 0, 5, 0, 3, 0, 0, 0, -5, 5, 0, 0, 0, -3, 0, -5, 0, 0, 0, 0, 2, 0}
+	pos := bgfparser.NewPosition().WithBoard(board)
+
+	var moves []map[string]interface{}
+	for turn := 0; turn < 1000; turn++ {
+		player, onRoll := 1, "O"
+		if turn%2 == 1 {
+			player, onRoll = -1, "X"
+		}
+		roll := rolls[turn%len(rolls)]
+		pos.WithOnRoll(onRoll).WithDice(roll[0], roll[1])
+
+		plays, err := pos.LegalMoves()
+		if err != nil {
+			t.Fatalf("LegalMoves failed: %v", err)
+		}
+		from, to := []int{}, []int{}
+		if len(plays) > 0 {
+			for _, m := range plays[0] {
+				from, to = append(from, m.From), append(to, m.To)
+			}
+			if pos, err = pos.ApplyMoves(plays[0]); err != nil {
+				t.Fatalf("ApplyMoves failed: %v", err)
+			}
+		}
+		moves = append(moves, map[string]interface{}{
+			"type": "amove", "player": player, "red": roll[0], "green": roll[1], "from": from, "to": to,
+		})
+
+		if pos.BorneOff(onRoll) == 15 {
+			return moves, pos.Board, player
+		}
+	}
+	t.Fatal("Game did not finish")
+	return nil, [26]int{}, 0
+}
+
+func TestMatch_FinalPositions(t *testing.T) {
+	rolls := [][2]int{{3, 1}, {6, 4}, {5, 2}, {6, 6}, {4, 3}, {2, 1}, {5, 5}, {6, 5}, {3, 3}, {4, 2}, {1, 1}}
+	moves1, board1, winner1 := playGame(t, rolls)
+	moves2, board2, winner2 := playGame(t, rolls[1:])
+
+	// Each game is worth one point
+	scores := [3][2]int{} // Green and Red before each game, then final
+	for i, winner := range []int{winner1, winner2} {
+		scores[i+1] = scores[i]
+		if winner > 0 {
+			scores[i+1][0]++
+		} else {
+			scores[i+1][1]++
+		}
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"matchlen": 3, "nameGreen": "Alice", "nameRed": "Bob",
+		"finalGreen": scores[2][0], "finalRed": scores[2][1],
+		"games": []map[string]interface{}{
+			{"scoreGreen": 0, "scoreRed": 0, "wonPoints": winner1, "moves": moves1},
+			{"scoreGreen": scores[1][0], "scoreRed": scores[1][1], "wonPoints": winner2, "moves": moves2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	positions, err := parseJSONMatch(t, string(data)).FinalPositions()
+	if err != nil {
+		t.Fatalf("FinalPositions failed: %v", err)
+	}
+	if len(positions) != 2 {
+		t.Fatalf("Got %d positions, want 2", len(positions))
+	}
+	for i, want := range []struct {
+		board  [26]int
+		winner int
+	}{{board1, winner1}, {board2, winner2}} {
+		pos := positions[i]
+		winner, loser := "X", "O"
+		if want.winner > 0 {
+			winner, loser = "O", "X"
+		}
+		if pos.Winner != winner {
+			t.Errorf("Game %d: Winner = %q, want %q", i+1, pos.Winner, winner)
+		}
+		if pos.Off[winner] != 15 || pos.Off[loser] >= 15 {
+			t.Errorf("Game %d: Off = %v, want 15 for %s only", i+1, pos.Off, winner)
+		}
+		if pos.Board != want.board {
+			t.Errorf("Game %d: Board = %v, want %v", i+1, pos.Board, want.board)
+		}
+		if pos.ScoreO != scores[i+1][0] || pos.ScoreX != scores[i+1][1] || pos.MatchLength != 3 {
+			t.Errorf("Game %d: score %d-%d of %d, want %d-%d of 3",
+				i+1, pos.ScoreO, pos.ScoreX, pos.MatchLength, scores[i+1][0], scores[i+1][1])
+		}
+		if pos.PlayerO != "Alice" || pos.PlayerX != "Bob" {
+			t.Errorf("Game %d: players = %q / %q, want Alice / Bob", i+1, pos.PlayerO, pos.PlayerX)
+		}
+	}
+
+	// Games without checker plays end on the opening board
+	match, err := bgfparser.ParseBGF("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("ParseBGF failed: %v", err)
+	}
+	if positions, err = match.FinalPositions(); err != nil || len(positions) != 6 {
+		t.Fatalf("FinalPositions = %d positions, %v; want 6", len(positions), err)
+	}
+	if last := positions[5]; last.Winner != "O" || last.ScoreO != 6 || last.PipCount["X"] != 167 {
+		t.Errorf("Last game = winner %q, Green on %d, Red pips %d; want O, 6, 167", last.Winner, last.ScoreO, last.PipCount["X"])
+	}
+
+	// A play that does not fit the board fails
+	bad := parseJSONMatch(t, `{"games":[{"wonPoints":1,"moves":[`+
+		`{"type":"amove","player":1,"red":2,"green":1,"from":[3],"to":[1]}]}]}`)
+	if _, err := bad.FinalPositions(); err == nil || !strings.Contains(err.Error(), "game 1, move 1") {
+		t.Errorf("FinalPositions error = %v, want one naming game 1, move 1", err)
+	}
+	if _, err := (&bgfparser.Match{}).FinalPositions(); err == nil {
+		t.Error("FinalPositions without data should fail")
+	}
+}
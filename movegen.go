@@ -0,0 +1,165 @@
+package bgfparser
+
+import "fmt"
+
+// LegalMoves returns every legal play for the player on roll with p.Dice.
+// Each play lists its checker moves in the order they are made, one per die,
+// from the mover's perspective like ParsedMoves. Plays reaching the same
+// position are returned once, and nil is returned when no checker can move.
+//
+// The usual rules apply: checkers on the bar enter first, as many dice as
+// possible must be played (four with doubles), a single playable die must be
+// the higher one when either could be played, and bearing off requires all
+// checkers in the home board, using a higher die only from the highest point.
+func (p *Position) LegalMoves() ([][]CheckerMove, error) {
+	if p.OnRoll != "X" && p.OnRoll != "O" {
+		return nil, fmt.Errorf("cannot generate moves: no player on roll")
+	}
+	if !p.Dice.Valid() {
+		return nil, fmt.Errorf("cannot generate moves: invalid dice %s", p.Dice)
+	}
+	d1, d2 := p.Dice[0], p.Dice[1]
+
+	var b moveBoard
+	opp := opponent(p.OnRoll)
+	for point := 1; point <= 24; point++ {
+		b.own[point] = p.checkersAt(p.OnRoll, point)
+		b.opp[point] = p.checkersAt(opp, 25-point)
+	}
+	b.own[PointBar] = p.OnBar[p.OnRoll]
+
+	g := &moveGenerator{}
+	if p.Dice.IsDouble() {
+		g.search(b, []int{d1, d1, d1, d1}, nil, nil)
+	} else {
+		g.search(b, []int{d1, d2}, nil, nil)
+		g.search(b, []int{d2, d1}, nil, nil)
+	}
+
+	return g.legalPlays(max(d1, d2)), nil
+}
+
+// moveBoard is the board seen by the player on roll: own holds the player's
+// checkers on each point (own[PointBar] for the bar) and opp the opponent's
+// checkers on the same points, both numbered from the player's side
+type moveBoard struct {
+	own [26]int
+	opp [26]int
+}
+
+// allHome reports whether every checker of the player is in the home board
+func (b *moveBoard) allHome() bool {
+	for point := 7; point <= PointBar; point++ {
+		if b.own[point] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// canMove reports whether a checker can move from the given point with a die
+func (b *moveBoard) canMove(from, die int) bool {
+	if b.own[from] == 0 || (b.own[PointBar] > 0 && from != PointBar) {
+		return false
+	}
+
+	to := from - die
+	if to > PointOff {
+		return b.opp[to] < 2
+	}
+
+	// Bearing off, with a larger die only from the highest occupied point
+	if !b.allHome() {
+		return false
+	}
+	if to < PointOff {
+		for point := from + 1; point <= 6; point++ {
+			if b.own[point] > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// move returns the board after moving a checker from the given point with a
+// die, hitting a blot on the destination, along with the checker move made
+func (b moveBoard) move(from, die int) (moveBoard, CheckerMove) {
+	m := CheckerMove{From: from, To: max(from-die, PointOff)}
+	b.own[from]--
+	if m.To != PointOff {
+		b.own[m.To]++
+		if b.opp[m.To] == 1 {
+			b.opp[m.To] = 0
+			m.Hit = true
+		}
+	}
+	return b, m
+}
+
+// generatedPlay is a candidate play with the dice it used and its resulting board
+type generatedPlay struct {
+	moves []CheckerMove
+	dice  []int
+	board moveBoard
+}
+
+// moveGenerator collects the plays found by a depth-first search over the dice
+type moveGenerator struct {
+	plays []generatedPlay
+}
+
+// search tries every checker with the first remaining die, recording the play
+// once no die is left or the next die cannot be played
+func (g *moveGenerator) search(b moveBoard, dice []int, moves []CheckerMove, used []int) {
+	if len(dice) > 0 {
+		moved := false
+		for from := PointBar; from >= 1; from-- {
+			if !b.canMove(from, dice[0]) {
+				continue
+			}
+			moved = true
+			next, m := b.move(from, dice[0])
+			g.search(next, dice[1:],
+				append(append([]CheckerMove(nil), moves...), m),
+				append(append([]int(nil), used...), dice[0]))
+		}
+		if moved {
+			return
+		}
+	}
+	g.plays = append(g.plays, generatedPlay{moves: moves, dice: used, board: b})
+}
+
+// legalPlays keeps the plays using the most dice, or the higher die when only
+// one of two different dice can be played, dropping plays reaching a position
+// already reached
+func (g *moveGenerator) legalPlays(highDie int) [][]CheckerMove {
+	maxDice := 0
+	usesHighDie := false
+	for _, play := range g.plays {
+		maxDice = max(maxDice, len(play.dice))
+	}
+	if maxDice == 0 {
+		return nil
+	}
+	for _, play := range g.plays {
+		if len(play.dice) == 1 && play.dice[0] == highDie {
+			usesHighDie = true
+		}
+	}
+
+	var plays [][]CheckerMove
+	seen := make(map[moveBoard]bool)
+	for _, play := range g.plays {
+		if len(play.dice) < maxDice || seen[play.board] {
+			continue
+		}
+		if maxDice == 1 && usesHighDie && play.dice[0] != highDie {
+			continue
+		}
+		seen[play.board] = true
+		plays = append(plays, play.moves)
+	}
+	return plays
+}
@@ -0,0 +1,436 @@
+package bgfparser
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Special point numbers used in CheckerMove, matching the BGF move encoding
+const (
+	PointOff = 0  // Checker borne off
+	PointBar = 25 // Checker entering from the bar
+)
+
+// CheckerMove represents a single checker moving from one point to another.
+// Points are numbered 1-24 from the perspective of the player making the move,
+// with PointBar for bar entry and PointOff for bearing off.
+type CheckerMove struct {
+	From int  `json:"from"`
+	To   int  `json:"to"`
+	Hit  bool `json:"hit"`
+}
+
+// ParsedMoves parses the free-form Move string into individual checker moves
+//
+// Supported notations:
+//
+//	"19/18, 14/12"   slash notation, comma or space separated
+//	"13-11 24-23"    dash notation
+//	"13/7*"          hit marker on the destination point
+//	"bar/22 6/off"   bar entry and bearing off
+//	"13/11(2)"       repeated move count
+//	"24/18*/13"      chained moves through intermediate points
+func (e Evaluation) ParsedMoves() ([]CheckerMove, error) {
+	return parseMoveString(e.Move)
+}
+
+// EquityOfMove returns the evaluation of the given move. Moves are compared
+// by their checker moves, ignoring order, separators and hit markers, so
+// "14/12 19/18" finds the evaluation stored as "19/18, 14/12".
+func (p *Position) EquityOfMove(move string) (Evaluation, bool) {
+	i := evaluationIndex(p.Evaluations, move)
+	if i < 0 {
+		return Evaluation{}, false
+	}
+	return p.Evaluations[i], true
+}
+
+// EquitySpread returns the equity difference between the best and the
+// second-best checker play, a cheap measure of how hard the decision is.
+// It returns 0 with fewer than two evaluations.
+func (p *Position) EquitySpread() float64 {
+	if len(p.Evaluations) < 2 {
+		return 0
+	}
+	return math.Abs(p.Evaluations[0].Equity - p.Evaluations[1].Equity)
+}
+
+// WinPct returns the winning chances of the evaluation as a percentage
+func (e Evaluation) WinPct() float64 {
+	return e.Win * 100
+}
+
+// BestMove returns the evaluation marked as best, or the first one listed
+// (ranked first) when none is marked. ok is false when the position has no
+// evaluations.
+func (p *Position) BestMove() (best *Evaluation, ok bool) {
+	if len(p.Evaluations) == 0 {
+		return nil, false
+	}
+	for i := range p.Evaluations {
+		if p.Evaluations[i].IsBest {
+			return &p.Evaluations[i], true
+		}
+	}
+	return &p.Evaluations[0], true
+}
+
+// evaluationIndex returns the index of the evaluation of move, compared
+// regardless of notation, or -1 if the move was not evaluated
+func evaluationIndex(evals []Evaluation, move string) int {
+	key := moveKey(move)
+	if key == "" {
+		return -1
+	}
+	for i, e := range evals {
+		if moveKey(e.Move) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// moveKey returns a normalized form of a move string: its checker moves
+// sorted, or the whitespace-normalized string when it cannot be parsed
+func moveKey(move string) string {
+	moves, err := parseMoveString(move)
+	if err != nil {
+		return strings.Join(strings.Fields(strings.ReplaceAll(move, ",", " ")), " ")
+	}
+
+	parts := make([]string, len(moves))
+	for i, m := range moves {
+		parts[i] = fmt.Sprintf("%d/%d", m.From, m.To)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
+
+// moveCountRe matches a repeated move count suffix like "(2)"
+var moveCountRe = regexp.MustCompile(`^(.*)\((\d)\)$`)
+
+// parseMoveString splits a move string into checker moves
+func parseMoveString(move string) ([]CheckerMove, error) {
+	tokens := strings.Fields(strings.ReplaceAll(move, ",", " "))
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	var moves []CheckerMove
+	for _, token := range tokens {
+		parsed, err := parseMoveToken(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid move %q: %v", move, err)
+		}
+		moves = append(moves, parsed...)
+	}
+
+	return moves, nil
+}
+
+// parseMoveToken parses a single move token such as "13/11(2)" or "bar/22*"
+func parseMoveToken(token string) ([]CheckerMove, error) {
+	count := 1
+	if matches := moveCountRe.FindStringSubmatch(token); len(matches) == 3 {
+		count, _ = strconv.Atoi(matches[2])
+		if count < 1 || count > 4 {
+			return nil, fmt.Errorf("invalid repeat count in %q", token)
+		}
+		token = matches[1]
+	}
+
+	// Slash notation takes precedence, dash notation is the legacy format
+	sep := "/"
+	if !strings.Contains(token, sep) {
+		sep = "-"
+	}
+
+	segments := strings.Split(token, sep)
+	if len(segments) < 2 {
+		return nil, fmt.Errorf("missing destination in %q", token)
+	}
+
+	points := make([]int, len(segments))
+	hits := make([]bool, len(segments))
+	for i, segment := range segments {
+		if strings.HasSuffix(segment, "*") {
+			hits[i] = true
+			segment = strings.TrimRight(segment, "*")
+		}
+
+		point, err := parseMovePoint(segment)
+		if err != nil {
+			return nil, err
+		}
+		points[i] = point
+	}
+
+	var chain []CheckerMove
+	for i := 1; i < len(points); i++ {
+		from, to := points[i-1], points[i]
+		if from == PointOff || to == PointBar {
+			return nil, fmt.Errorf("invalid direction in %q", token)
+		}
+		if from <= to {
+			return nil, fmt.Errorf("checker must move forward in %q", token)
+		}
+		chain = append(chain, CheckerMove{From: from, To: to, Hit: hits[i]})
+	}
+
+	moves := make([]CheckerMove, 0, len(chain)*count)
+	for i := 0; i < count; i++ {
+		moves = append(moves, chain...)
+	}
+
+	return moves, nil
+}
+
+// parseMovePoint converts a point label into its numeric value
+func parseMovePoint(label string) (int, error) {
+	switch strings.ToLower(label) {
+	case "bar":
+		return PointBar, nil
+	case "off":
+		return PointOff, nil
+	}
+
+	point, err := strconv.Atoi(label)
+	if err != nil || point < PointOff || point > PointBar {
+		return 0, fmt.Errorf("invalid point %q", label)
+	}
+	return point, nil
+}
+
+// ApplyMoves plays a sequence of checker moves for the player on roll and
+// returns the resulting position. The receiver is not modified.
+//
+// Each checker move is validated against the board and, when known, the dice:
+// the source must hold one of the mover's checkers, checkers on the bar must
+// enter first, the destination must not be blocked, and bearing off requires
+// all checkers in the home board. A blot on the destination is hit and sent to
+// the bar. Moves spanning several dice (e.g. "13/7" with 4-2) are played
+// through their intermediate points.
+//
+// The returned position has the other player on roll, no dice, recomputed pip
+// counts, and no identifiers or analysis, since those describe the original position.
+func (p *Position) ApplyMoves(moves []CheckerMove) (*Position, error) {
+	if p.OnRoll != "X" && p.OnRoll != "O" {
+		return nil, fmt.Errorf("cannot apply moves: no player on roll")
+	}
+
+	state := &moveState{
+		pos:    p.clone(),
+		player: p.OnRoll,
+	}
+	if state.pos.OnBar == nil {
+		state.pos.OnBar = make(map[string]int)
+	}
+	if p.Dice[0] > 0 && p.Dice[1] > 0 {
+		state.dice = []int{p.Dice[0], p.Dice[1]}
+		if p.Dice.IsDouble() {
+			state.dice = append(state.dice, p.Dice[0], p.Dice[0])
+		}
+	}
+
+	for _, move := range moves {
+		if err := state.play(move); err != nil {
+			return nil, fmt.Errorf("illegal move %d/%d: %v", move.From, move.To, err)
+		}
+	}
+
+	result := state.pos
+	result.OnRoll = opponent(p.OnRoll)
+	result.Dice = Dice{}
+	result.PositionID = ""
+	result.MatchID = ""
+	result.XGID = ""
+	result.Evaluations = nil
+	result.CubeDecisions = nil
+	result.CubelessEquity = 0
+	result.CubefulEquity = 0
+	result.EquityStdDev = 0
+	result.PipCount = map[string]int{
+		"X": result.computePipCount("X"),
+		"O": result.computePipCount("O"),
+	}
+
+	return result, nil
+}
+
+// moveState tracks the board and remaining dice while applying moves
+type moveState struct {
+	pos    *Position
+	player string
+	dice   []int // nil when the dice are unknown and not validated
+}
+
+// play applies a single checker move, consuming the matching dice
+func (s *moveState) play(move CheckerMove) error {
+	if move.From <= move.To || move.From > PointBar || move.To < PointOff {
+		return fmt.Errorf("invalid points")
+	}
+
+	if s.dice == nil {
+		return s.step(move.From, move.To)
+	}
+
+	distance := move.From - move.To
+
+	// Single die matching the distance exactly
+	if i := s.findDie(distance); i >= 0 {
+		if err := s.step(move.From, move.To); err != nil {
+			return err
+		}
+		s.useDie(i)
+		return nil
+	}
+
+	// Bearing off with a larger die from the highest occupied point
+	if move.To == PointOff {
+		for i, die := range s.dice {
+			if die > distance && !s.hasCheckersAbove(move.From) {
+				if err := s.step(move.From, move.To); err != nil {
+					return err
+				}
+				s.useDie(i)
+				return nil
+			}
+		}
+	}
+
+	// Several dice played by the same checker
+	for _, path := range s.dicePaths(distance) {
+		if !s.pathOpen(move.From, path) {
+			continue
+		}
+		from := move.From
+		for _, die := range path {
+			if err := s.step(from, from-die); err != nil {
+				return err
+			}
+			s.useDie(s.findDie(die))
+			from -= die
+		}
+		return nil
+	}
+
+	return fmt.Errorf("no dice match a distance of %d", distance)
+}
+
+// step moves a single checker without any dice bookkeeping
+func (s *moveState) step(from, to int) error {
+	pos, player := s.pos, s.player
+	opp := opponent(player)
+
+	if from == PointBar {
+		if pos.OnBar[player] == 0 {
+			return fmt.Errorf("no checker on the bar")
+		}
+	} else {
+		if pos.OnBar[player] > 0 {
+			return fmt.Errorf("checkers on the bar must enter first")
+		}
+		if pos.checkersAt(player, from) == 0 {
+			return fmt.Errorf("no checker on point %d", from)
+		}
+	}
+
+	if to == PointOff {
+		if s.hasCheckersAbove(6) {
+			return fmt.Errorf("cannot bear off before all checkers are home")
+		}
+	} else {
+		switch pos.checkersAt(opp, 25-to) {
+		case 0:
+		case 1:
+			pos.Board[boardIndex(player, to)] = 0
+			pos.OnBar[opp]++
+		default:
+			return fmt.Errorf("point %d is blocked", to)
+		}
+	}
+
+	sign := playerSign(player)
+	if from == PointBar {
+		pos.OnBar[player]--
+	} else {
+		pos.Board[boardIndex(player, from)] -= sign
+	}
+	if to != PointOff {
+		pos.Board[boardIndex(player, to)] += sign
+	} else if pos.Off != nil {
+		pos.Off[player]++
+	}
+
+	return nil
+}
+
+// hasCheckersAbove reports whether the player has checkers on the bar or
+// on any point higher than the given one
+func (s *moveState) hasCheckersAbove(point int) bool {
+	if s.pos.OnBar[s.player] > 0 {
+		return true
+	}
+	for p := point + 1; p <= 24; p++ {
+		if s.pos.checkersAt(s.player, p) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// findDie returns the index of a remaining die with the given value, or -1
+func (s *moveState) findDie(value int) int {
+	for i, die := range s.dice {
+		if die == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// useDie removes the die at index i from the remaining dice
+func (s *moveState) useDie(i int) {
+	s.dice = append(s.dice[:i], s.dice[i+1:]...)
+}
+
+// dicePaths returns the orderings of remaining dice that sum to distance
+func (s *moveState) dicePaths(distance int) [][]int {
+	var paths [][]int
+	if len(s.dice) < 2 {
+		return paths
+	}
+
+	if s.dice[0] == s.dice[1] {
+		die := s.dice[0]
+		if distance%die == 0 && distance/die <= len(s.dice) {
+			path := make([]int, distance/die)
+			for i := range path {
+				path[i] = die
+			}
+			paths = append(paths, path)
+		}
+		return paths
+	}
+
+	if s.dice[0]+s.dice[1] == distance {
+		paths = append(paths, []int{s.dice[0], s.dice[1]}, []int{s.dice[1], s.dice[0]})
+	}
+	return paths
+}
+
+// pathOpen reports whether every intermediate point of a multi-dice move is open
+func (s *moveState) pathOpen(from int, path []int) bool {
+	opp := opponent(s.player)
+	for _, die := range path[:len(path)-1] {
+		from -= die
+		if from <= PointOff || s.pos.checkersAt(opp, 25-from) >= 2 {
+			return false
+		}
+	}
+	return true
+}
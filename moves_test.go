@@ -0,0 +1,380 @@
+package bgfparser_test
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kevung/bgfparser"
+)
+
+func TestEvaluation_ParsedMoves(t *testing.T) {
+	tests := []struct {
+		name string
+		move string
+		want []bgfparser.CheckerMove
+	}{
+		{
+			name: "Slash notation",
+			move: "19/18, 14/12",
+			want: []bgfparser.CheckerMove{{From: 19, To: 18}, {From: 14, To: 12}},
+		},
+		{
+			name: "Dash notation",
+			move: "13-11 24-23",
+			want: []bgfparser.CheckerMove{{From: 13, To: 11}, {From: 24, To: 23}},
+		},
+		{
+			name: "Hit marker",
+			move: "13/7*, 8/7",
+			want: []bgfparser.CheckerMove{{From: 13, To: 7, Hit: true}, {From: 8, To: 7}},
+		},
+		{
+			name: "Bar entry",
+			move: "bar/22*, 13/9",
+			want: []bgfparser.CheckerMove{{From: 25, To: 22, Hit: true}, {From: 13, To: 9}},
+		},
+		{
+			name: "Bear off",
+			move: "6/off 5/off",
+			want: []bgfparser.CheckerMove{{From: 6, To: 0}, {From: 5, To: 0}},
+		},
+		{
+			name: "Repeated count",
+			move: "13/11(2) 6/4(2)",
+			want: []bgfparser.CheckerMove{
+				{From: 13, To: 11}, {From: 13, To: 11},
+				{From: 6, To: 4}, {From: 6, To: 4},
+			},
+		},
+		{
+			name: "Chained move",
+			move: "24/18*/13",
+			want: []bgfparser.CheckerMove{{From: 24, To: 18, Hit: true}, {From: 18, To: 13}},
+		},
+		{
+			name: "Empty move",
+			move: "",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eval := bgfparser.Evaluation{Move: tt.move}
+			got, err := eval.ParsedMoves()
+			if err != nil {
+				t.Fatalf("ParsedMoves(%q) failed: %v", tt.move, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsedMoves(%q) = %v, want %v", tt.move, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluation_ParsedMovesInvalid(t *testing.T) {
+	for _, move := range []string{"13", "11/13", "off/5", "5/bar", "13/x", "13/11(9)"} {
+		eval := bgfparser.Evaluation{Move: move}
+		if _, err := eval.ParsedMoves(); err == nil {
+			t.Errorf("ParsedMoves(%q) expected error", move)
+		}
+	}
+}
+
+func TestPosition_ApplyMoves(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	moves, err := pos.Evaluations[0].ParsedMoves()
+	if err != nil {
+		t.Fatalf("ParsedMoves failed: %v", err)
+	}
+
+	result, err := pos.ApplyMoves(moves)
+	if err != nil {
+		t.Fatalf("ApplyMoves failed: %v", err)
+	}
+
+	// Best move 19/18, 14/12
+	want := pos.Board
+	want[19]--
+	want[18]++
+	want[14]--
+	want[12]++
+	if result.Board != want {
+		t.Errorf("Board = %v, want %v", result.Board, want)
+	}
+
+	if result.PipCount["X"] != 108 {
+		t.Errorf("PipCount[X] = %d, want 108", result.PipCount["X"])
+	}
+	if result.PipCount["O"] != 52 {
+		t.Errorf("PipCount[O] = %d, want 52", result.PipCount["O"])
+	}
+	if result.OnRoll != "O" {
+		t.Errorf("OnRoll = %q, want O", result.OnRoll)
+	}
+
+	// The original position must be left untouched
+	if pos.Board[14] != 1 || pos.OnRoll != "X" {
+		t.Error("ApplyMoves modified the original position")
+	}
+}
+
+func TestPosition_ApplyMovesHitAndBar(t *testing.T) {
+	txt := `XGID=-a----E-C---eE---c-e----B-:0:0:1:21:0:0:0:3:10
+`
+	pos, err := bgfparser.ParseTXTFromReader(strings.NewReader(txt))
+	if err != nil {
+		t.Fatalf("ParseTXTFromReader failed: %v", err)
+	}
+	pos.Dice = [2]int{5, 1}
+
+	// X hits the O blot on point 1 with 6/1*, then plays 8/7
+	result, err := pos.ApplyMoves([]bgfparser.CheckerMove{{From: 6, To: 1, Hit: true}, {From: 8, To: 7}})
+	if err != nil {
+		t.Fatalf("ApplyMoves failed: %v", err)
+	}
+	if result.Board[1] != 1 {
+		t.Errorf("Board[1] = %d, want 1", result.Board[1])
+	}
+	if result.OnBar["O"] != 1 {
+		t.Errorf("OnBar[O] = %d, want 1", result.OnBar["O"])
+	}
+
+	// O must now enter from the bar before moving anything else
+	result.Dice = [2]int{3, 4}
+	if _, err := result.ApplyMoves([]bgfparser.CheckerMove{{From: 13, To: 10}}); err == nil {
+		t.Error("Expected error when moving while on the bar")
+	}
+	entered, err := result.ApplyMoves([]bgfparser.CheckerMove{{From: 25, To: 22}, {From: 13, To: 9}})
+	if err != nil {
+		t.Fatalf("ApplyMoves bar entry failed: %v", err)
+	}
+	if entered.OnBar["O"] != 0 {
+		t.Errorf("OnBar[O] = %d, want 0", entered.OnBar["O"])
+	}
+	if entered.Board[3] != -1 {
+		t.Errorf("Board[3] = %d, want -1", entered.Board[3])
+	}
+}
+
+func TestPosition_ApplyMovesIllegal(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		moves []bgfparser.CheckerMove
+	}{
+		{"Empty point", []bgfparser.CheckerMove{{From: 9, To: 8}}},
+		{"Blocked point", []bgfparser.CheckerMove{{From: 24, To: 23}}},
+		{"Wrong die", []bgfparser.CheckerMove{{From: 14, To: 10}}},
+		{"Bear off not home", []bgfparser.CheckerMove{{From: 1, To: 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := pos.ApplyMoves(tt.moves); err == nil {
+				t.Error("Expected error for illegal move")
+			}
+		})
+	}
+}
+
+func TestPosition_EquityOfMove(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	tests := []struct {
+		query  string
+		found  bool
+		equity float64
+	}{
+		{"14/12 19/18", true, -0.492},
+		{"19/18, 14/12", true, -0.492},
+		{"  3/1   19/18 ", true, -0.545},
+		{"18/17,19/17", true, -0.577},
+		{"14/11", true, -0.585},
+		{"24/23 13/11", false, 0},
+		{"", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			eval, ok := pos.EquityOfMove(tt.query)
+			if ok != tt.found {
+				t.Fatalf("EquityOfMove(%q) found = %v, want %v", tt.query, ok, tt.found)
+			}
+			if ok && eval.Equity != tt.equity {
+				t.Errorf("EquityOfMove(%q) equity = %v, want %v", tt.query, eval.Equity, tt.equity)
+			}
+		})
+	}
+}
+
+func TestPosition_LegalMoves(t *testing.T) {
+	// X to enter from the bar against a five-point board, with an O blot on 17
+	barEntry := bgfparser.Position{OnRoll: "X", OnBar: map[string]int{"X": 1}}
+	for _, point := range []int{19, 20, 21, 23, 24} {
+		barEntry.Board[point] = -2
+	}
+	barEntry.Board[17] = -1
+	barEntry.Board[1] = -4
+
+	// The same position with O to enter from the bar
+	oBarEntry := bgfparser.Position{OnRoll: "O", OnBar: map[string]int{"O": 1}}
+	for i, n := range barEntry.Board {
+		oBarEntry.Board[25-i] = -n
+	}
+
+	// X's last outfield checker can play either die but not both
+	highDie := bgfparser.Position{OnRoll: "X", OnBar: map[string]int{}}
+	highDie.Board[14] = 1
+	highDie.Board[1] = 14
+	highDie.Board[3] = -2
+
+	// X bearing off its last two checkers
+	bearOff := bgfparser.Position{OnRoll: "X", OnBar: map[string]int{}}
+	bearOff.Board[6] = 1
+	bearOff.Board[1] = 1
+	bearOff.Board[20] = -2
+
+	opening, err := bgfparser.ParseXGID("-b----E-C---eE---c-e----B-:0:0:1:00:0:0:0:0:10")
+	if err != nil {
+		t.Fatalf("ParseXGID failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		pos   bgfparser.Position
+		dice  [2]int
+		count int
+		want  [][]bgfparser.CheckerMove // Checked when set
+	}{
+		{
+			name: "Forced bar entry with hit", pos: barEntry, dice: [2]int{3, 5}, count: 1,
+			want: [][]bgfparser.CheckerMove{{{From: 25, To: 22}, {From: 22, To: 17, Hit: true}}},
+		},
+		{
+			name: "Forced bar entry for O", pos: oBarEntry, dice: [2]int{5, 3}, count: 1,
+			want: [][]bgfparser.CheckerMove{{{From: 25, To: 22}, {From: 22, To: 17, Hit: true}}},
+		},
+		{name: "Dance", pos: barEntry, dice: [2]int{5, 5}, count: 0},
+		{name: "Dance with doubles blocked on both dice", pos: barEntry, dice: [2]int{6, 6}, count: 0},
+		{
+			name: "Higher die must be played", pos: highDie, dice: [2]int{5, 6}, count: 1,
+			want: [][]bgfparser.CheckerMove{{{From: 14, To: 8}}},
+		},
+		{
+			name: "Doubles play four times", pos: highDie, dice: [2]int{2, 2}, count: 1,
+			want: [][]bgfparser.CheckerMove{{{From: 14, To: 12}, {From: 12, To: 10}, {From: 10, To: 8}, {From: 8, To: 6}}},
+		},
+		{
+			name: "Bear off", pos: bearOff, dice: [2]int{6, 1}, count: 2,
+			want: [][]bgfparser.CheckerMove{
+				{{From: 6, To: 0}, {From: 1, To: 0}},
+				{{From: 6, To: 5}, {From: 5, To: 0}},
+			},
+		},
+		{name: "Opening 2-1", pos: *opening, dice: [2]int{2, 1}, count: 15},
+		{name: "Opening 6-5", pos: *opening, dice: [2]int{6, 5}, count: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := tt.pos
+			pos.Dice = tt.dice
+			plays, err := pos.LegalMoves()
+			if err != nil {
+				t.Fatalf("LegalMoves failed: %v", err)
+			}
+			if len(plays) != tt.count {
+				t.Fatalf("Got %d plays, want %d: %v", len(plays), tt.count, plays)
+			}
+			if tt.want != nil && !reflect.DeepEqual(plays, tt.want) {
+				t.Errorf("Plays = %v, want %v", plays, tt.want)
+			}
+
+			// Every play is accepted by ApplyMoves
+			for _, play := range plays {
+				if _, err := pos.ApplyMoves(play); err != nil {
+					t.Errorf("ApplyMoves(%v) failed: %v", play, err)
+				}
+			}
+		})
+	}
+
+	if _, err := (&bgfparser.Position{OnRoll: "X"}).LegalMoves(); err == nil {
+		t.Error("Expected an error without dice")
+	}
+}
+
+func TestPosition_EquitySpread(t *testing.T) {
+	tests := []struct {
+		name  string
+		evals []bgfparser.Evaluation
+		want  float64
+	}{
+		{"No evaluations", nil, 0},
+		{"One evaluation", []bgfparser.Evaluation{{Move: "13/9", Equity: 0.25}}, 0},
+		{"Two evaluations", []bgfparser.Evaluation{{Move: "13/9", Equity: -0.492}, {Move: "24/20", Equity: -0.545}}, 0.053},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := &bgfparser.Position{Evaluations: tt.evals}
+			if got := pos.EquitySpread(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("EquitySpread() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	pos, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if got := pos.EquitySpread(); math.Abs(got-0.053) > 1e-9 {
+		t.Errorf("EquitySpread() = %v, want 0.053", got)
+	}
+}
+
+func TestPosition_BestMove(t *testing.T) {
+	evals := []bgfparser.Evaluation{
+		{Rank: 1, Move: "13/9", Equity: -0.492, Win: 0.254},
+		{Rank: 2, Move: "24/20", Equity: -0.545, Win: 0.227, IsBest: true},
+	}
+	pos := &bgfparser.Position{Evaluations: evals}
+	best, ok := pos.BestMove()
+	if !ok || best.Move != "24/20" {
+		t.Errorf("BestMove() = %v, %v; want the starred 24/20", best, ok)
+	}
+	if math.Abs(best.WinPct()-22.7) > 1e-9 {
+		t.Errorf("WinPct() = %v, want 22.7", best.WinPct())
+	}
+
+	// Without a star the first ranked move is the best
+	parsed, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	best, ok = parsed.BestMove()
+	if !ok || best.Rank != 1 || best.Move != "19/18, 14/12" {
+		t.Errorf("BestMove() = %v, %v; want the rank 1 move 19/18, 14/12", best, ok)
+	}
+	if math.Abs(best.WinPct()-25.4) > 1e-9 {
+		t.Errorf("WinPct() = %v, want 25.4", best.WinPct())
+	}
+
+	if best, ok := (&bgfparser.Position{}).BestMove(); ok || best != nil {
+		t.Errorf("BestMove() without evaluations = %v, %v; want nil, false", best, ok)
+	}
+}
@@ -0,0 +1,154 @@
+package bgfparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kevung/bgfparser/internal/smile"
+)
+
+// OrderedMap is a decoded object that keeps its keys in the order they
+// appear in the file. Nested objects are also *OrderedMap.
+// It marshals to a JSON object with the same key order.
+type OrderedMap struct {
+	Keys   []string
+	Values map[string]interface{}
+}
+
+// MarshalJSON writes the object members in key order
+func (o *OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.Keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.Values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// set stores value under key, appending the key when it is new
+func (o *OrderedMap) set(key string, value interface{}) {
+	if _, ok := o.Values[key]; !ok {
+		o.Keys = append(o.Keys, key)
+	}
+	o.Values[key] = value
+}
+
+// orderedObjects returns the object entries of a decoded ordered array,
+// skipping other values like objects does
+func orderedObjects(values []interface{}) []*OrderedMap {
+	var objs []*OrderedMap
+	for _, v := range values {
+		if obj, ok := v.(*OrderedMap); ok {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+// orderedFromSmile converts SMILE ordered objects into OrderedMap values
+func orderedFromSmile(v interface{}) interface{} {
+	switch node := v.(type) {
+	case smile.OrderedObject:
+		o := &OrderedMap{Keys: make([]string, 0, len(node)), Values: make(map[string]interface{}, len(node))}
+		for _, kv := range node {
+			o.Keys = append(o.Keys, kv.Key)
+			o.Values[kv.Key] = orderedFromSmile(kv.Value)
+		}
+		return o
+	case []interface{}:
+		for i, child := range node {
+			node[i] = orderedFromSmile(child)
+		}
+	}
+	return v
+}
+
+// unordered converts OrderedMap values back into plain maps
+func unordered(v interface{}) interface{} {
+	switch node := v.(type) {
+	case *OrderedMap:
+		m := make(map[string]interface{}, len(node.Keys))
+		for _, key := range node.Keys {
+			m[key] = unordered(node.Values[key])
+		}
+		return m
+	case []interface{}:
+		out := make([]interface{}, len(node))
+		for i, child := range node {
+			out[i] = unordered(child)
+		}
+		return out
+	}
+	return v
+}
+
+// decodeOrderedJSON decodes JSON data, representing objects as *OrderedMap
+func decodeOrderedJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeOrderedJSONValue(dec)
+}
+
+// decodeOrderedJSONValue decodes the next JSON value from dec
+func decodeOrderedJSONValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			o := &OrderedMap{Values: make(map[string]interface{})}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ := keyTok.(string)
+				val, err := decodeOrderedJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				if _, ok := o.Values[key]; !ok {
+					o.Keys = append(o.Keys, key)
+				}
+				o.Values[key] = val
+			}
+			_, err := dec.Token()
+			return o, err
+		case '[':
+			arr := make([]interface{}, 0)
+			for dec.More() {
+				val, err := decodeOrderedJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, val)
+			}
+			_, err := dec.Token()
+			return arr, err
+		}
+		return nil, fmt.Errorf("unexpected JSON delimiter %v", t)
+	case json.Number:
+		// Match encoding/json's default float64 numbers
+		return t.Float64()
+	}
+	return tok, nil
+}
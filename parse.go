@@ -0,0 +1,161 @@
+package bgfparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+)
+
+// sniffSize is the number of leading bytes inspected to detect the file format
+const sniffSize = 512
+
+// Parse parses either a BGF match or a TXT position from an io.Reader,
+// detecting the format from the content rather than a file extension.
+// Content starting with a JSON header whose format is "BGF" is parsed as a
+// BGF match, anything else as a TXT position.
+//
+// The result is either a *Match or a *Position:
+//
+//	result, err := bgfparser.Parse(reader)
+//	if err != nil {
+//	    return err
+//	}
+//	switch v := result.(type) {
+//	case *bgfparser.Match:
+//	    fmt.Println("match:", v.String())
+//	case *bgfparser.Position:
+//	    fmt.Println("position:", v.XGID)
+//	}
+func Parse(reader io.Reader) (interface{}, error) {
+	bufReader := bufio.NewReaderSize(reader, sniffSize)
+
+	// Peek returns the available bytes along with an error on short input
+	head, _ := bufReader.Peek(sniffSize)
+	if isBGFHeader(head) {
+		return ParseBGFFromReader(bufReader)
+	}
+	return ParseTXTFromReader(bufReader)
+}
+
+// ParseFile parses a BGF or TXT file from disk, detecting the format from its content.
+// See Parse for the possible result types.
+func ParseFile(filename string) (interface{}, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, &ParseError{File: filename, Message: err.Error()}
+	}
+	defer file.Close()
+
+	result, err := Parse(file)
+	if err != nil {
+		// Add filename to error if not already present
+		if parseErr, ok := err.(*ParseError); ok && parseErr.File == "" {
+			parseErr.File = filename
+			return nil, parseErr
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// isBGFHeader reports whether data starts with a BGF JSON header line,
+// ignoring a UTF-8 BOM and leading blank lines like ParseBGFFromReader does
+func isBGFHeader(data []byte) bool {
+	data = bytes.TrimLeft(bytes.TrimPrefix(data, utf8BOM), " \t\r\n")
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		data = data[:i]
+	}
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("{")) {
+		return false
+	}
+
+	var header struct {
+		Format string `json:"format"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return false
+	}
+	return header.Format == "BGF"
+}
+
+// TXT dialects reported by DetectTXTDialect
+const (
+	DialectBGBlitz = "bgblitz"
+	DialectXG      = "xg"
+	DialectGnuBG   = "gnubg"
+	DialectUnknown = "unknown"
+)
+
+// dialectSignatures lists, per dialect, lines only that program writes.
+// All three draw the same ASCII board, and BGBlitz writes an XGID line
+// too, so neither tells the dialects apart.
+var dialectSignatures = []struct {
+	dialect  string
+	patterns []*regexp.Regexp
+}{
+	{DialectBGBlitz, []*regexp.Regexp{
+		regexp.MustCompile(`BGBlitz`),
+		regexp.MustCompile(`Position-ID:.*Match-ID:`),
+		regexp.MustCompile(`\bmwp\s*/`),
+		regexp.MustCompile(`\bto move\b`),
+	}},
+	{DialectXG, []*regexp.Regexp{
+		regexp.MustCompile(`eXtreme Gammon`),
+		regexp.MustCompile(`^\s*Score is X:\s*\d+\s+O:\s*\d+`),
+		regexp.MustCompile(`^\s*Pip count\s+X:\s*\d+\s+O:\s*\d+`),
+		regexp.MustCompile(`\beq:\s*[+-]?\d`),
+		regexp.MustCompile(`^\s*(Player|Opponent):\s+\d+(\.\d+)?%`),
+		regexp.MustCompile(`^\s*[XO] to play\b`),
+	}},
+	{DialectGnuBG, []*regexp.Regexp{
+		regexp.MustCompile(`GNU Backgammon`),
+		regexp.MustCompile(`Position ID\s*:`),
+		regexp.MustCompile(`Match ID\s*:`),
+		regexp.MustCompile(`^\s*Pip counts:\s*O\s+\d+,\s*X\s+\d+`),
+		regexp.MustCompile(`Cubeful equities:`),
+		regexp.MustCompile(`Proper cube action:`),
+	}},
+}
+
+// DetectTXTDialect reads a position text export from r and reports which
+// program wrote it: DialectBGBlitz, DialectXG, DialectGnuBG, or
+// DialectUnknown when no signature line is found or the dialects tie.
+// ParseTXTFromReader understands BGBlitz and XG exports.
+func DetectTXTDialect(r io.Reader) (string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), DefaultMaxLineLength)
+	scanner.Split(scanTXTLines)
+
+	hits := make(map[string]int)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		for _, sig := range dialectSignatures {
+			for _, re := range sig.patterns {
+				if re.MatchString(line) {
+					hits[sig.dialect]++
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", &ParseError{Line: lineNum + 1, Message: err.Error(), Err: err}
+	}
+
+	dialect, best := DialectUnknown, 0
+	for _, sig := range dialectSignatures {
+		switch n := hits[sig.dialect]; {
+		case n > best:
+			dialect, best = sig.dialect, n
+		case n == best && n > 0:
+			dialect = DialectUnknown
+		}
+	}
+	return dialect, nil
+}
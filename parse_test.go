@@ -0,0 +1,100 @@
+package bgfparser_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/kevung/bgfparser"
+)
+
+func TestParse_DetectsBGF(t *testing.T) {
+	content := `{"format":"BGF","version":"1.0","compress":false,"useSmile":false}` + "\n" +
+		`{"nameGreen":"Alice","nameRed":"Bob"}`
+
+	result, err := bgfparser.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	match, ok := result.(*bgfparser.Match)
+	if !ok {
+		t.Fatalf("Parse returned %T, want *bgfparser.Match", result)
+	}
+	if match.Data["nameGreen"] != "Alice" {
+		t.Errorf("Data[nameGreen] = %v, want Alice", match.Data["nameGreen"])
+	}
+}
+
+func TestParse_DetectsTXT(t *testing.T) {
+	content := `XGID=-b----E-C---eE---c-e----B-:0:0:1:21:0:0:0:3:10
+Player1 - 0 Player2 - 0 in a 3 point match.
+`
+
+	result, err := bgfparser.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	pos, ok := result.(*bgfparser.Position)
+	if !ok {
+		t.Fatalf("Parse returned %T, want *bgfparser.Position", result)
+	}
+	if pos.MatchLength != 3 {
+		t.Errorf("MatchLength = %d, want 3", pos.MatchLength)
+	}
+}
+
+func TestParseFile_DetectsTXT(t *testing.T) {
+	result, err := bgfparser.ParseFile("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if _, ok := result.(*bgfparser.Position); !ok {
+		t.Errorf("ParseFile returned %T, want *bgfparser.Position", result)
+	}
+}
+
+func TestDetectTXTDialect(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"test/2025-11-04/01_checkerPosition_EN.txt", bgfparser.DialectBGBlitz},
+		{"test/2025-11-04/03_DT_FR.txt", bgfparser.DialectBGBlitz},
+		{"test/2025-11-04/05_NRT_JP.txt", bgfparser.DialectBGBlitz},
+		{"test/fixtures/bearoff_EN.txt", bgfparser.DialectBGBlitz},
+		{"test/fixtures/dialect_xg_EN.txt", bgfparser.DialectXG},
+		{"test/fixtures/xg_checker_EN.txt", bgfparser.DialectXG},
+		{"test/fixtures/xg_cube_EN.txt", bgfparser.DialectXG},
+		{"test/fixtures/dialect_gnubg_EN.txt", bgfparser.DialectGnuBG},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			file, err := os.Open(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			got, err := bgfparser.DetectTXTDialect(file)
+			if err != nil {
+				t.Fatalf("DetectTXTDialect failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DetectTXTDialect = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Only the board, drawn alike by every program
+	pos, err := bgfparser.ParseXGID("-b----E-C---eE---c-e----B-:0:0:1:52:0:0:0:5:10")
+	if err != nil {
+		t.Fatalf("ParseXGID failed: %v", err)
+	}
+	got, err := bgfparser.DetectTXTDialect(strings.NewReader(pos.ToBoardASCII()))
+	if err != nil || got != bgfparser.DialectUnknown {
+		t.Errorf("DetectTXTDialect(board only) = %q, %v, want %q", got, err, bgfparser.DialectUnknown)
+	}
+}
@@ -1,6 +1,15 @@
 package bgfparser_test
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/kevung/bgfparser"
@@ -166,3 +175,546 @@ func TestEvaluation_EquityValues(t *testing.T) {
 		}
 	}
 }
+
+func TestParseTXT_MalformedXGID(t *testing.T) {
+	tests := []struct {
+		name string
+		xgid string
+	}{
+		{"Too short", "-b----E-C---eE---c-e----B:0:0:1:21:0:0:0:3:10"},
+		{"Too long", "-b----E-C---eE---c-e----B--:0:0:1:21:0:0:0:3:10"},
+		{"Invalid character", "-b----E-C---eE---c-e---zB-:0:0:1:21:0:0:0:3:10"},
+		{"Multibyte character", "-b----E-C---eE---c-e---éB-:0:0:1:21:0:0:0:3:10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txt := "O: Player1 150  X: Player2 140\nXGID=" + tt.xgid + "\n"
+			_, err := bgfparser.ParseTXTFromReader(strings.NewReader(txt))
+			if err == nil {
+				t.Fatal("Expected error for malformed XGID")
+			}
+
+			parseErr, ok := err.(*bgfparser.ParseError)
+			if !ok {
+				t.Fatalf("Expected *ParseError, got %T", err)
+			}
+			if parseErr.Line != 2 {
+				t.Errorf("ParseError.Line = %d, want 2", parseErr.Line)
+			}
+		})
+	}
+}
+
+func TestParseTXT_ScannerErrorLine(t *testing.T) {
+	// The third line exceeds the maximum line length
+	txt := "O: Player1 150  X: Player2 140\n\n" + strings.Repeat("x", bgfparser.DefaultMaxLineLength+1) + "\n"
+	_, err := bgfparser.ParseTXTFromReader(strings.NewReader(txt))
+	if err == nil {
+		t.Fatal("Expected error for oversized line")
+	}
+
+	var parseErr *bgfparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected *ParseError, got %T", err)
+	}
+	if parseErr.Line != 3 {
+		t.Errorf("ParseError.Line = %d, want 3", parseErr.Line)
+	}
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("Expected error to wrap bufio.ErrTooLong, got %v", err)
+	}
+}
+
+func TestParseTXT_LongLines(t *testing.T) {
+	// A line longer than bufio.Scanner's default 64KB limit
+	txt := "XGID=-b----E-C---eE---c-e----B-:0:0:1:21:0:0:0:3:10\n" + strings.Repeat(" ", 200*1024) + "|\n"
+
+	pos, err := bgfparser.ParseTXTFromReader(strings.NewReader(txt))
+	if err != nil {
+		t.Fatalf("ParseTXTFromReader failed on a long line: %v", err)
+	}
+	if pos.XGID == "" {
+		t.Error("XGID not parsed")
+	}
+
+	// A configured cap below the line length fails clearly
+	_, err = bgfparser.ParseTXTFromReaderWithOptions(strings.NewReader(txt), bgfparser.TXTOptions{MaxLineLength: 100 * 1024})
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Fatalf("Expected bufio.ErrTooLong, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "maximum length of 102400 bytes") {
+		t.Errorf("Error %q does not name the limit", err)
+	}
+}
+
+func TestParseBGF_TruncatedFile(t *testing.T) {
+	match, err := bgfparser.ParseBGF("test/fixtures/truncated.bgf")
+	if err != nil {
+		t.Fatalf("ParseBGF failed: %v", err)
+	}
+
+	for key := range match.Data {
+		if strings.HasPrefix(key, "_") {
+			t.Errorf("Data contains internal key %q", key)
+		}
+	}
+
+	if match.Partial == nil {
+		t.Fatal("Expected Partial to be set for a truncated file")
+	}
+	if len(match.DecodingWarnings) == 0 {
+		t.Error("Expected a decoding warning for a truncated file")
+	}
+
+	// Everything decoded before the truncation is kept
+	if match.Data["nameGreen"] != "Alice" || match.Data["nameRed"] != "Bob" {
+		t.Errorf("Player names not decoded: %v", match.Data)
+	}
+	games := match.Games()
+	if len(games) != 1 || games[0].WonPoints != 2 {
+		t.Errorf("Games = %+v, want one game with 2 won points", games)
+	}
+}
+
+func TestParseBGF_GzipVariants(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		wantWarning bool
+	}{
+		{"Trailing byte", "test/fixtures/trailing_byte.bgf", true},
+		{"Multiple gzip members", "test/fixtures/multistream.bgf", false},
+		{"Gzip filename and comment", "test/fixtures/gzip_extras.bgf", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, err := bgfparser.ParseBGF(tt.file)
+			if err != nil {
+				t.Fatalf("ParseBGF failed: %v", err)
+			}
+
+			if match.Data["nameGreen"] != "Alice" || match.Data["nameRed"] != "Bob" {
+				t.Errorf("Unexpected data: %v", match.Data)
+			}
+
+			if got := len(match.DecodingWarnings) > 0; got != tt.wantWarning {
+				t.Errorf("DecodingWarnings = %v, want warning: %v", match.DecodingWarnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestParseBGF_GzipHeaderExtras(t *testing.T) {
+	// Same match as compressed_smile.bgf, in a gzip member with FNAME and FCOMMENT set
+	data, err := os.ReadFile("test/fixtures/gzip_extras.bgf")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if flags := data[bytes.IndexByte(data, '\n')+4]; flags&0x18 != 0x18 {
+		t.Fatalf("Fixture gzip flags = %#x, want FNAME and FCOMMENT", flags)
+	}
+
+	match, err := bgfparser.ParseBGFFromReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	want, err := bgfparser.ParseBGF("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("ParseBGF failed: %v", err)
+	}
+	if !reflect.DeepEqual(match.Data, want.Data) {
+		t.Errorf("Data differs from the plain gzip fixture: %v", match.Data)
+	}
+}
+
+func TestParseBGF_SmileFlagWithJSONPayload(t *testing.T) {
+	// Flagged useSmile, but the gzip payload is plain JSON
+	match, err := bgfparser.ParseBGF("test/fixtures/smile_flag_json.bgf")
+	if err != nil {
+		t.Fatalf("ParseBGF failed: %v", err)
+	}
+	if !match.UseSmile {
+		t.Error("UseSmile = false, want the header flag kept")
+	}
+	if match.Data["nameGreen"] != "Alice" || match.Data["matchlen"] != float64(3) {
+		t.Errorf("Data = %v, want the JSON payload", match.Data)
+	}
+	if count, err := match.GameCount(); err != nil || count != 1 {
+		t.Errorf("GameCount() = %d, %v, want 1", count, err)
+	}
+	if len(match.DecodingWarnings) != 1 || !strings.Contains(match.DecodingWarnings[0], "decoded as JSON") {
+		t.Errorf("DecodingWarnings = %v, want one warning about the JSON payload", match.DecodingWarnings)
+	}
+}
+
+func TestParseBGF_TruncatedGzip(t *testing.T) {
+	data, err := os.ReadFile("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	headerLen := bytes.IndexByte(data, '\n') + 1
+
+	// The complete file decodes without error
+	full, err := bgfparser.ParseBGFFromReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	if len(full.Games()) != 6 {
+		t.Fatalf("Expected 6 games, got %d", len(full.Games()))
+	}
+
+	for _, cut := range []int{headerLen + 5, headerLen + 40, len(data) - 60, len(data) - 4} {
+		t.Run(fmt.Sprintf("Cut at %d", cut), func(t *testing.T) {
+			match, err := bgfparser.ParseBGFFromReader(bytes.NewReader(data[:cut]))
+			if !errors.Is(err, bgfparser.ErrTruncatedBGF) {
+				t.Fatalf("Expected ErrTruncatedBGF, got %v", err)
+			}
+			if want := fmt.Sprintf("truncated after %d bytes", cut-headerLen); !strings.Contains(err.Error(), want) {
+				t.Errorf("Error %q does not contain %q", err, want)
+			}
+			if match == nil || match.Partial == nil {
+				t.Fatal("Expected a partial match")
+			}
+
+			// Later cuts keep more of the decoded data
+			if cut > headerLen+40 && match.Data["nameGreen"] != "Alice" {
+				t.Errorf("Partial data lost the player name: %v", match.Data)
+			}
+			if len(match.Games()) > 6 {
+				t.Errorf("Too many games in partial data: %d", len(match.Games()))
+			}
+		})
+	}
+}
+
+func TestBGFToJSON(t *testing.T) {
+	golden, err := os.ReadFile("test/fixtures/compressed_smile.json")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	convert := func(indent bool) []byte {
+		f, err := os.Open("test/fixtures/compressed_smile.bgf")
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer f.Close()
+
+		var out bytes.Buffer
+		if err := bgfparser.BGFToJSON(f, &out, indent); err != nil {
+			t.Fatalf("BGFToJSON failed: %v", err)
+		}
+		return out.Bytes()
+	}
+
+	if indented := convert(true); !bytes.Equal(indented, golden) {
+		t.Errorf("Indented output differs from golden file:\n%s", indented)
+	}
+
+	var compactGolden bytes.Buffer
+	if err := json.Compact(&compactGolden, golden); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	compact := convert(false)
+	if !bytes.Equal(bytes.TrimSpace(compact), compactGolden.Bytes()) {
+		t.Errorf("Compact output differs from golden file:\n%s", compact)
+	}
+	if bytes.Count(compact, []byte("\n")) != 1 {
+		t.Error("Compact output should be a single line")
+	}
+}
+
+func TestBGFToJSON_InternalKeys(t *testing.T) {
+	header := `{"format":"BGF","version":"1.0","compress":false,"useSmile":false}` + "\n"
+	data := `{"_debug":1,"games":[{"_raw":"x","wonPoints":2}]}`
+
+	var out bytes.Buffer
+	if err := bgfparser.BGFToJSON(strings.NewReader(header+data), &out, false); err != nil {
+		t.Fatalf("BGFToJSON failed: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != `{"games":[{"wonPoints":2}]}` {
+		t.Errorf("BGFToJSON = %s", got)
+	}
+}
+
+func TestParseTXT_GarbledPlayerHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		txt  string
+	}{
+		{"Negative pip count", "O: Green -52  X: Red 111\n"},
+		{"Garbled pip count", "O: Green 5x2  X: Red 111\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXTFromReader(strings.NewReader(tt.txt))
+			if err != nil {
+				t.Fatalf("ParseTXTFromReader failed: %v", err)
+			}
+
+			if len(pos.Warnings) != 1 || !strings.Contains(pos.Warnings[0], "player O") {
+				t.Errorf("Warnings = %v, want one warning for player O", pos.Warnings)
+			}
+			if pos.PipCount["O"] != 0 {
+				t.Errorf("PipCount[O] = %d, want 0", pos.PipCount["O"])
+			}
+			if pos.PipCount["X"] != 111 {
+				t.Errorf("PipCount[X] = %d, want 111", pos.PipCount["X"])
+			}
+		})
+	}
+
+	// Well-formed headers produce no warnings
+	pos, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if len(pos.Warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", pos.Warnings)
+	}
+}
+
+func TestParser_ParseTXT(t *testing.T) {
+	files, err := filepath.Glob("test/2025-11-04/*.txt")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("No TXT fixtures found: %v", err)
+	}
+
+	// The same Parser is reused for every file
+	parser := bgfparser.NewParser(bgfparser.TXTOptions{})
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		want, err := bgfparser.ParseTXTFromReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("ParseTXTFromReader(%s) failed: %v", file, err)
+		}
+		got, err := parser.ParseTXT(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Parser.ParseTXT(%s) failed: %v", file, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Parser result differs from ParseTXTFromReader", file)
+		}
+	}
+}
+
+func benchmarkTXTData(b *testing.B) []byte {
+	b.Helper()
+	data, err := os.ReadFile("test/2025-11-04/03_DT_EN.txt")
+	if err != nil {
+		b.Fatalf("ReadFile failed: %v", err)
+	}
+	return data
+}
+
+func BenchmarkParseTXTFromReader(b *testing.B) {
+	data := benchmarkTXTData(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := bgfparser.ParseTXTFromReader(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParser_ParseTXT reuses the line buffer across calls, allocating
+// about 64 KB less per parse than BenchmarkParseTXTFromReader
+func BenchmarkParser_ParseTXT(b *testing.B) {
+	data := benchmarkTXTData(b)
+	parser := bgfparser.NewParser(bgfparser.TXTOptions{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parser.ParseTXT(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParseBGFFromReader parses the same file repeatedly, as a server
+// would. Reusing the staging buffers and gzip readers across parses saves
+// about 50 KB of allocations per parse of this file.
+func BenchmarkParseBGFFromReader(b *testing.B) {
+	data, err := os.ReadFile("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		b.Fatalf("ReadFile failed: %v", err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := bgfparser.ParseBGFFromReader(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseBGFFromReader_BufferReuse(t *testing.T) {
+	smileData, err := os.ReadFile("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	jsonData := []byte(`{"format":"BGF","version":"1.0","compress":false,"useSmile":false}` + "\n" +
+		`{"nameGreen":"Carol","nameRed":"Dave","matchlen":3}`)
+
+	first, err := bgfparser.ParseBGFFromReader(bytes.NewReader(smileData))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	want, err := json.Marshal(first.Data)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	// Later parses reusing the buffers neither change earlier results nor
+	// see data left over from them
+	for i := 0; i < 20; i++ {
+		input := smileData
+		if i%2 == 0 {
+			input = jsonData
+		}
+		match, err := bgfparser.ParseBGFFromReader(bytes.NewReader(input))
+		if err != nil {
+			t.Fatalf("Parse %d failed: %v", i, err)
+		}
+		if i%2 == 0 && (len(match.Data) != 3 || match.Data["nameGreen"] != "Carol") {
+			t.Errorf("Parse %d: Data = %v, want only the JSON payload", i, match.Data)
+		}
+	}
+
+	got, err := json.Marshal(first.Data)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("Data of the first parse changed after later parses")
+	}
+}
+
+func TestParseTXT_PatternFields(t *testing.T) {
+	// Fields read through the package-level patterns: IDs, score, dice,
+	// evaluation ranks, probabilities and cubeless/cubeful equities
+	checker := func(t *testing.T, pos *bgfparser.Position) {
+		if pos.Dice != [2]int{1, 2} {
+			t.Errorf("Dice = %v, want [1 2]", pos.Dice)
+		}
+		if len(pos.Evaluations) != 5 {
+			t.Fatalf("Expected 5 evaluations, got %d", len(pos.Evaluations))
+		}
+		eval := pos.Evaluations[1]
+		if eval.Rank != 2 || eval.PrintedRank != 2 || eval.Move != "19/18, 3/1" {
+			t.Errorf("Evaluation 2 = rank %d (printed %d) %q", eval.Rank, eval.PrintedRank, eval.Move)
+		}
+		if eval.Equity != -0.545 || eval.Diff != -0.053 {
+			t.Errorf("Evaluation 2 equity = %v (%v), want -0.545 (-0.053)", eval.Equity, eval.Diff)
+		}
+		if eval.Win != 0.227 || eval.LoseG != 0.385 || eval.LoseBG != 0.005 {
+			t.Errorf("Evaluation 2 probabilities = %v %v %v", eval.Win, eval.LoseG, eval.LoseBG)
+		}
+	}
+	cube := func(t *testing.T, pos *bgfparser.Position) {
+		if pos.CubelessEquity != 0.344 || pos.CubefulEquity != 0.41 {
+			t.Errorf("Equities = %v / %v, want 0.344 / 0.41", pos.CubelessEquity, pos.CubefulEquity)
+		}
+	}
+
+	tests := []struct {
+		file       string
+		positionID string
+		score      [3]int // X, O and match length
+		check      func(*testing.T, *bgfparser.Position)
+	}{
+		{"test/2025-11-04/01_checkerPosition_EN.txt", "b9sBCIC5bYDQAA", [3]int{3, 6, 7}, checker},
+		{"test/2025-11-04/01_checkerPosition_DE.txt", "b9sBCIC5bYDQAA", [3]int{3, 6, 7}, checker},
+		{"test/2025-11-04/03_DT_EN.txt", "Mw5jkCQyz+AhAg", [3]int{2, 4, 9}, cube},
+		{"test/2025-11-04/03_DT_JP.txt", "Mw5jkCQyz+AhAg", [3]int{2, 4, 9}, cube},
+	}
+
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.file), func(t *testing.T) {
+			pos, err := bgfparser.ParseTXT(tt.file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+			if pos.PositionID != tt.positionID || pos.XGID == "" {
+				t.Errorf("PositionID = %q, XGID = %q", pos.PositionID, pos.XGID)
+			}
+			if score := [3]int{pos.ScoreX, pos.ScoreO, pos.MatchLength}; score != tt.score {
+				t.Errorf("Score = %v, want %v", score, tt.score)
+			}
+			tt.check(t, pos)
+		})
+	}
+}
+
+// BenchmarkParseTXT_LargeFile parses a position with 500 evaluated moves,
+// exercising the per-line patterns. With the patterns compiled inside the
+// parse helpers it allocated over ten times more and ran about twice as long.
+func BenchmarkParseTXT_LargeFile(b *testing.B) {
+	data, err := os.ReadFile("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		b.Fatalf("ReadFile failed: %v", err)
+	}
+	head, _, _ := strings.Cut(string(data), "Evaluation")
+
+	var large strings.Builder
+	large.WriteString(head + "Evaluation  (EMG)\n ==========\n")
+	for i := 1; i <= 500; i++ {
+		fmt.Fprintf(&large, "  %d.   0.124 mwp /  -0.492  (-0.%03d)  19/18, 14/12\n", i, i%1000)
+		large.WriteString("       0.254  0.000  0.000  -  0.746  0.338  0.004\n\n")
+	}
+	input := []byte(large.String())
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		pos, err := bgfparser.ParseTXTFromReader(bytes.NewReader(input))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(pos.Evaluations) != 500 {
+			b.Fatalf("Got %d evaluations, want 500", len(pos.Evaluations))
+		}
+	}
+}
+
+func TestDecompressBGFPayload(t *testing.T) {
+	data, err := os.ReadFile("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	header, payload, err := bgfparser.DecompressBGFPayload(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecompressBGFPayload failed: %v", err)
+	}
+	if !header.Compress || !header.UseSmile || header.Format != "BGF" {
+		t.Errorf("Header = %+v, want a compressed SMILE BGF header", header)
+	}
+	if header.Data != nil {
+		t.Error("Header should not hold decoded data")
+	}
+	if !bytes.HasPrefix(payload, []byte(":)\n")) {
+		t.Errorf("Payload starts with %q, want the SMILE header", payload[:min(len(payload), 4)])
+	}
+
+	// The payload is what ParseBGF decodes
+	match, err := bgfparser.ParseBGFFromReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	if !bytes.Contains(payload, []byte(match.Data["nameGreen"].(string))) {
+		t.Error("Payload does not contain the player names")
+	}
+
+	// A cut gzip stream returns the bytes decompressed so far
+	_, payload, err = bgfparser.DecompressBGFPayload(bytes.NewReader(data[:len(data)-20]))
+	if !errors.Is(err, bgfparser.ErrTruncatedBGF) {
+		t.Errorf("Expected ErrTruncatedBGF, got %v", err)
+	}
+	if !bytes.HasPrefix(payload, []byte(":)\n")) {
+		t.Error("Truncated payload should still start with the SMILE header")
+	}
+}
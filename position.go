@@ -0,0 +1,377 @@
+package bgfparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// opponent returns the other player letter
+func opponent(player string) string {
+	if player == "X" {
+		return "O"
+	}
+	return "X"
+}
+
+// playerSign returns the board sign used for a player's checkers
+// (positive for X, negative for O)
+func playerSign(player string) int {
+	if player == "O" {
+		return -1
+	}
+	return 1
+}
+
+// boardIndex converts a point number from a player's perspective into a Board index.
+// Board indices follow X's perspective, so O's points are mirrored.
+func boardIndex(player string, point int) int {
+	if player == "O" {
+		return 25 - point
+	}
+	return point
+}
+
+// checkersAt returns the number of the player's checkers on a point
+// given from that player's perspective (0 if the point is empty or held by the opponent)
+func (p *Position) checkersAt(player string, point int) int {
+	n := p.Board[boardIndex(player, point)] * playerSign(player)
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// clone returns a deep copy of the position
+func (p *Position) clone() *Position {
+	c := *p
+
+	c.OnBar = make(map[string]int, len(p.OnBar))
+	for k, v := range p.OnBar {
+		c.OnBar[k] = v
+	}
+	c.PipCount = make(map[string]int, len(p.PipCount))
+	for k, v := range p.PipCount {
+		c.PipCount[k] = v
+	}
+	if p.Off != nil {
+		c.Off = make(map[string]int, len(p.Off))
+		for k, v := range p.Off {
+			c.Off[k] = v
+		}
+	}
+	if p.Evaluations != nil {
+		c.Evaluations = append([]Evaluation(nil), p.Evaluations...)
+	}
+	if p.CubeDecisions != nil {
+		c.CubeDecisions = append([]CubeDecision(nil), p.CubeDecisions...)
+	}
+	if p.Warnings != nil {
+		c.Warnings = append([]string(nil), p.Warnings...)
+	}
+
+	return &c
+}
+
+// HomeBoard returns the number of the player's ("X" or "O") checkers on each
+// home board point, indexed from the player's 1-point (index 0) to 6-point (index 5)
+func (p *Position) HomeBoard(player string) [6]int {
+	var home [6]int
+	for point := 1; point <= 6; point++ {
+		home[point-1] = p.checkersAt(player, point)
+	}
+	return home
+}
+
+// BorneOff returns the number of the player's ("X" or "O") checkers borne off,
+// that is 15 minus the checkers on the board and bar
+func (p *Position) BorneOff(player string) int {
+	onBoard := p.OnBar[player]
+	for point := 1; point <= 24; point++ {
+		onBoard += p.checkersAt(player, point)
+	}
+	if onBoard >= 15 {
+		return 0
+	}
+	return 15 - onBoard
+}
+
+// colorWords maps the localized color names BGBlitz shows instead of player
+// names to "red" or "green"
+var colorWords = map[string]string{
+	"red":   "red",
+	"rouge": "red",
+	"rot":   "red",
+	"赤":     "red",
+	"green": "green",
+	"vert":  "green",
+	"grün":  "green",
+	"緑":     "green",
+}
+
+// ColorOf returns the color shown for a player ("X" or "O") as printed in the
+// file, e.g. "Red", "Rouge", "Rot" or "赤". It returns "" when the player is
+// shown by name rather than by color.
+func (p *Position) ColorOf(player string) string {
+	name := p.PlayerX
+	if player == "O" {
+		name = p.PlayerO
+	}
+	if _, ok := colorWords[strings.ToLower(name)]; !ok {
+		return ""
+	}
+	return name
+}
+
+// PlayerOfColor returns the player ("X" or "O") shown with the given color,
+// which may be given in any supported language ("red", "Vert", "緑"...).
+// It returns "" when no player is shown with that color.
+func (p *Position) PlayerOfColor(color string) string {
+	want, ok := colorWords[strings.ToLower(strings.TrimSpace(color))]
+	if !ok {
+		return ""
+	}
+	for _, player := range []string{"X", "O"} {
+		if c := p.ColorOf(player); c != "" && colorWords[strings.ToLower(c)] == want {
+			return player
+		}
+	}
+	return ""
+}
+
+// computePipCount returns the pip count of a player from the board and bar
+func (p *Position) computePipCount(player string) int {
+	pips := p.OnBar[player] * 25
+	for point := 1; point <= 24; point++ {
+		pips += p.checkersAt(player, point) * point
+	}
+	return pips
+}
+
+// PipLead returns the player ahead in the pip count ("X" or "O", the one
+// with fewer pips to bear off) and the margin. Equal counts return "" and 0.
+func (p *Position) PipLead() (player string, lead int) {
+	x, o := p.computePipCount("X"), p.computePipCount("O")
+	switch {
+	case x < o:
+		return "X", o - x
+	case o < x:
+		return "O", x - o
+	}
+	return "", 0
+}
+
+// GamePhase classifies the position based on the board alone.
+//
+// Returns one of:
+//
+//	"race"     no contact remains, every checker has passed the opponent's checkers
+//	"backgame" a side holds two or more anchors in the opponent's home board
+//	"holding"  a side holds an anchor on the opponent's 4, 5 or bar point while trailing in the race
+//	"contact"  any other position where the checkers can still interact
+func (p *Position) GamePhase() string {
+	if !p.IsContact() {
+		return "race"
+	}
+
+	for _, player := range []string{"X", "O"} {
+		if p.anchorsInOpponentHome(player) >= 2 {
+			return "backgame"
+		}
+	}
+
+	for _, player := range []string{"X", "O"} {
+		if p.hasHoldingAnchor(player) &&
+			p.computePipCount(player) > p.computePipCount(opponent(player)) {
+			return "holding"
+		}
+	}
+
+	return "contact"
+}
+
+// IsContact reports whether the checkers can still hit each other.
+// X moves from point 24 towards point 1 while O moves the other way, so contact
+// exists as long as X's rearmost checker is behind O's rearmost checker.
+// It scans the board at most once and does not allocate.
+func (p *Position) IsContact() bool {
+	// Rearmost X checker as a Board index (25 for the bar)
+	backX := 0
+	if p.OnBar["X"] > 0 {
+		backX = 25
+	} else {
+		for i := 24; i >= 1; i-- {
+			if p.Board[i] > 0 {
+				backX = i
+				break
+			}
+		}
+	}
+
+	// Rearmost O checker as a Board index (0 for the bar)
+	backO := 25
+	if p.OnBar["O"] > 0 {
+		backO = 0
+	} else {
+		for i := 1; i <= 24; i++ {
+			if p.Board[i] < 0 {
+				backO = i
+				break
+			}
+		}
+	}
+
+	return backX > backO
+}
+
+// anchorsInOpponentHome counts the points in the opponent's home board
+// (points 19-24 from the player's perspective) holding two or more of the player's checkers
+func (p *Position) anchorsInOpponentHome(player string) int {
+	anchors := 0
+	for point := 19; point <= 24; point++ {
+		if p.checkersAt(player, point) >= 2 {
+			anchors++
+		}
+	}
+	return anchors
+}
+
+// hasHoldingAnchor reports whether the player holds the opponent's 4, 5 or bar point
+func (p *Position) hasHoldingAnchor(player string) bool {
+	for _, point := range []int{18, 20, 21} {
+		if p.checkersAt(player, point) >= 2 {
+			return true
+		}
+	}
+	return false
+}
+
+// CanDouble reports whether the given player ("X" or "O") may offer the cube
+// this turn: the player is on roll and has not rolled yet, the cube is centered
+// or owned by the player, the game is not the Crawford game, and in match play
+// the current cube value does not already cover the points the player needs
+// to win the match (a dead cube).
+func (p *Position) CanDouble(player string) bool {
+	if player != "X" && player != "O" {
+		return false
+	}
+	if player != p.OnRoll || p.Dice.Valid() {
+		return false
+	}
+
+	if !p.CubeIsCentered() && p.CubeOwner != player {
+		return false
+	}
+
+	if p.MatchLength > 0 {
+		if p.Crawford {
+			return false
+		}
+
+		score := p.ScoreX
+		if player == "O" {
+			score = p.ScoreO
+		}
+		cube := p.CubeValue
+		if cube < 1 {
+			cube = 1
+		}
+		if score+cube >= p.MatchLength {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AwayScores returns the number of points each player still needs to win
+// the match, or 0 for both in a money game
+func (p *Position) AwayScores() (xAway, oAway int) {
+	if p.MatchLength <= 0 {
+		return 0, 0
+	}
+	return max(p.MatchLength-p.ScoreX, 0), max(p.MatchLength-p.ScoreO, 0)
+}
+
+// ScoreString describes the match score as away scores, e.g.
+// "X is 3-away, O is 1-away (Crawford)", or "Money game"
+func (p *Position) ScoreString() string {
+	if p.MatchLength <= 0 {
+		return "Money game"
+	}
+
+	xAway, oAway := p.AwayScores()
+	s := fmt.Sprintf("X is %d-away, O is %d-away", xAway, oAway)
+	switch {
+	case p.Crawford:
+		s += " (Crawford)"
+	case p.PostCrawford:
+		s += " (post-Crawford)"
+	}
+	return s
+}
+
+// AsPerspective returns a copy of the position with the analysis expressed
+// from the given player's point of view. If player is not on roll, move
+// equities and differences are negated, winning and losing chances swap, and
+// cube decision MWC and EMG values are inverted. The board is left unchanged;
+// use it for display only, as OnRoll still names the player on roll.
+func (p *Position) AsPerspective(player string) *Position {
+	c := p.clone()
+	if player == p.OnRoll || (player != "X" && player != "O") {
+		return c
+	}
+
+	c.CubelessEquity = -c.CubelessEquity
+	c.CubefulEquity = -c.CubefulEquity
+
+	for i := range c.Evaluations {
+		e := &c.Evaluations[i]
+		e.Equity = -e.Equity
+		e.Diff = -e.Diff
+		if e.hasProbabilities() {
+			e.Win = 1 - e.Win
+			e.WinG, e.LoseG = e.LoseG, e.WinG
+			e.WinBG, e.LoseBG = e.LoseBG, e.WinBG
+		}
+	}
+
+	for i := range c.CubeDecisions {
+		d := &c.CubeDecisions[i]
+		d.MWC = 1 - d.MWC
+		d.MWCDiff = -d.MWCDiff
+		d.EMG = -d.EMG
+		d.EMGDiff = -d.EMGDiff
+	}
+
+	return c
+}
+
+// hasProbabilities reports whether the evaluation carries outcome probabilities
+func (e Evaluation) hasProbabilities() bool {
+	return e.Win != 0 || e.WinG != 0 || e.WinBG != 0 || e.LoseG != 0 || e.LoseBG != 0
+}
+
+// Normalize canonicalizes fields that exporters write inconsistently, so that
+// equal positions compare and serialize identically: dice are sorted with the
+// higher die first, nil OnBar/PipCount/Off maps are initialized, player names are
+// trimmed, and the owner of a centered cube (see CubeIsCentered) is blanked.
+func (p *Position) Normalize() {
+	if p.Dice[0] < p.Dice[1] {
+		p.Dice[0], p.Dice[1] = p.Dice[1], p.Dice[0]
+	}
+
+	p.initMaps()
+
+	p.PlayerX = strings.TrimSpace(p.PlayerX)
+	p.PlayerO = strings.TrimSpace(p.PlayerO)
+
+	if p.CubeIsCentered() {
+		p.CubeOwner = ""
+	}
+}
+
+// CubeIsCentered reports whether the cube is in the middle: owned by neither
+// player, or still at 1 since an undoubled cube cannot be owned
+func (p *Position) CubeIsCentered() bool {
+	return p.CubeValue <= 1 || (p.CubeOwner != "X" && p.CubeOwner != "O")
+}
@@ -0,0 +1,998 @@
+package bgfparser_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevung/bgfparser"
+)
+
+// parseXGIDPosition builds a position from a single XGID line
+func parseXGIDPosition(t *testing.T, xgid string) *bgfparser.Position {
+	t.Helper()
+	pos, err := bgfparser.ParseTXTFromReader(strings.NewReader("XGID=" + xgid + "\n"))
+	if err != nil {
+		t.Fatalf("ParseTXTFromReader failed: %v", err)
+	}
+	return pos
+}
+
+func TestPosition_GamePhase(t *testing.T) {
+	tests := []struct {
+		name string
+		xgid string
+		want string
+	}{
+		{"Bear-off race", "---BADB------------bf---a-:1:1:1:00:4:0:0:7:10", "race"},
+		{"Opening position", "-b----E-C---eE---c-e----B-:0:0:1:00:0:0:0:7:10", "contact"},
+		{"Backgame", "-b-b--D-C---c----bbb---bB-:0:0:1:00:0:0:0:7:10", "backgame"},
+		{"Holding game", "-----BD-C----C-----aB-cbb-:0:0:1:00:0:0:0:7:10", "holding"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := parseXGIDPosition(t, tt.xgid)
+			if got := pos.GamePhase(); got != tt.want {
+				t.Errorf("GamePhase() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPosition_PipLead(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/bearoff_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	// X has 27 pips left and O 30
+	if player, lead := pos.PipLead(); player != "X" || lead != 3 {
+		t.Errorf("PipLead() = %q, %d, want X, 3", player, lead)
+	}
+
+	tests := []struct {
+		name   string
+		xgid   string
+		player string
+		lead   int
+	}{
+		{"O ahead of X on the bar", "-a-----------------------B:0:0:1:00:0:0:0:7:10", "O", 26},
+		{"Opening position", "-b----E-C---eE---c-e----B-:0:0:1:00:0:0:0:7:10", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player, lead := parseXGIDPosition(t, tt.xgid).PipLead()
+			if player != tt.player || lead != tt.lead {
+				t.Errorf("PipLead() = %q, %d, want %q, %d", player, lead, tt.player, tt.lead)
+			}
+		})
+	}
+}
+
+func TestPosition_IsContact(t *testing.T) {
+	tests := []struct {
+		name string
+		xgid string
+		want bool
+	}{
+		{"Opening position", "-b----E-C---eE---c-e----B-:0:0:1:00:0:0:0:7:10", true},
+		{"Pure race", "---BADB------------bf---a-:1:1:1:00:4:0:0:7:10", false},
+		{"Adjacent trailing checkers passed", "----------Aa--------------:0:0:1:00:0:0:0:7:10", false},
+		{"Adjacent trailing checkers facing", "----------aA--------------:0:0:1:00:0:0:0:7:10", true},
+		{"Checker on the bar", "-------------------------A:0:0:1:00:0:0:0:7:10", false},
+		{"Checker on the bar facing", "-a-----------------------A:0:0:1:00:0:0:0:7:10", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := parseXGIDPosition(t, tt.xgid)
+			if got := pos.IsContact(); got != tt.want {
+				t.Errorf("IsContact() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPosition_CanDouble(t *testing.T) {
+	tests := []struct {
+		name  string
+		xgid  string
+		wantX bool
+		wantO bool
+	}{
+		{"Centered cube", "-b----E-C---eE---c-e----B-:0:0:1:00:0:0:0:7:10", true, true},
+		{"Cube owned by X", "-b----E-C---eE---c-e----B-:1:1:1:00:0:0:0:7:10", true, false},
+		{"Cube owned by O", "-b----E-C---eE---c-e----B-:1:-1:1:00:0:0:0:7:10", false, true},
+		{"Crawford game", "-b----E-C---eE---c-e----B-:0:0:1:00:6:3:1:7:10", false, false},
+		{"Post-Crawford leader", "-b----E-C---eE---c-e----B-:0:0:1:00:6:3:0:7:10", false, true},
+		{"Dead cube for O", "-b----E-C---eE---c-e----B-:1:-1:1:00:2:5:0:7:10", false, false},
+		{"Money game", "-b----E-C---eE---c-e----B-:2:1:1:00:0:0:0:0:10", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Each player is asked when on roll, before rolling
+			pos := parseXGIDPosition(t, tt.xgid)
+			if got := pos.CanDouble("X"); got != tt.wantX {
+				t.Errorf("CanDouble(X) = %v, want %v", got, tt.wantX)
+			}
+			if pos.CanDouble("O") {
+				t.Error("CanDouble(O) = true for the player not on roll")
+			}
+			pos.OnRoll = "O"
+			if got := pos.CanDouble("O"); got != tt.wantO {
+				t.Errorf("CanDouble(O) = %v, want %v", got, tt.wantO)
+			}
+			if pos.CanDouble("X") {
+				t.Error("CanDouble(X) = true for the player not on roll")
+			}
+		})
+	}
+
+	// The cube can no longer be offered once the dice are rolled
+	rolled, err := bgfparser.ParseXGID("-b----E-C---eE---c-e----B-:0:0:1:52:0:0:0:7:10")
+	if err != nil {
+		t.Fatalf("ParseXGID failed: %v", err)
+	}
+	if rolled.CanDouble("X") {
+		t.Error("CanDouble(X) = true after rolling")
+	}
+}
+
+func TestPosition_ToXGID(t *testing.T) {
+	files := []string{
+		"test/2025-11-04/01_checkerPosition_EN.txt",
+		"test/2025-11-04/02_NDT_EN.txt",
+		"test/2025-11-04/03_DT_EN.txt",
+		"test/2025-11-04/04_DP_EN.txt",
+		"test/2025-11-04/05_NRT_EN.txt",
+		"test/2025-11-04/06_RT_EN.txt",
+		"test/2025-11-04/07_RP_EN.txt",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXT(file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+			if got := pos.ToXGID(); got != pos.XGID {
+				t.Errorf("ToXGID() = %q, want %q", got, pos.XGID)
+			}
+		})
+	}
+}
+
+func TestPosition_ToXGIDFields(t *testing.T) {
+	// The money game flag is the Jacoby rule, the maximum cube is kept
+	for _, xgid := range []string{
+		"-b----E-C---eE---c-e----B-:1:1:1:00:0:0:1:0:8",
+		"-b----E-C---eE---c-e----B-:1:1:1:00:0:0:0:0:10",
+		"-b----E-C---eE---c-e----B-:0:0:1:00:6:3:1:7:10",
+	} {
+		pos, err := bgfparser.ParseXGID(xgid)
+		if err != nil {
+			t.Fatalf("ParseXGID failed: %v", err)
+		}
+		if got := pos.ToXGID(); got != xgid {
+			t.Errorf("ToXGID() = %q, want %q", got, xgid)
+		}
+	}
+
+	money, err := bgfparser.ParseXGID("-b----E-C---eE---c-e----B-:1:1:1:00:0:0:1:0:8")
+	if err != nil {
+		t.Fatalf("ParseXGID failed: %v", err)
+	}
+	if !money.Rules.Jacoby || money.Crawford {
+		t.Errorf("Jacoby = %v, Crawford = %v, want the Jacoby rule only", money.Rules.Jacoby, money.Crawford)
+	}
+	built := bgfparser.NewPosition().WithOnRoll("X")
+	built.Rules.Jacoby = true
+	if got := built.ToXGID(); got != "--------------------------:0:0:1:00:0:0:1:0:10" {
+		t.Errorf("ToXGID() = %q, want the Jacoby flag and the default maximum cube", got)
+	}
+
+	// More than 15 checkers on a point cannot be encoded
+	var board [26]int
+	board[6] = 16
+	tall := bgfparser.NewPosition().WithBoard(board).WithOnRoll("X")
+	if got := tall.ToXGID(); got != "" {
+		t.Errorf("ToXGID() = %q, want \"\" for 16 checkers on a point", got)
+	}
+	other := bgfparser.NewPosition().WithBoard(board).WithOnRoll("X")
+	if tall.Equal(other) {
+		t.Error("Positions that cannot be encoded should not be equal")
+	}
+}
+
+func TestPosition_CanonicalKey(t *testing.T) {
+	en, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	fr, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_FR.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	if en.PlayerX == fr.PlayerX {
+		t.Fatal("Expected fixtures with different player names")
+	}
+	if en.CanonicalKey() != fr.CanonicalKey() {
+		t.Errorf("CanonicalKey differs: %q vs %q", en.CanonicalKey(), fr.CanonicalKey())
+	}
+	if !en.Equal(fr) {
+		t.Error("Expected positions to be equal")
+	}
+
+	// Dice order does not matter
+	fr.Dice[0], fr.Dice[1] = fr.Dice[1], fr.Dice[0]
+	if !en.Equal(fr) {
+		t.Error("Expected positions with swapped dice to be equal")
+	}
+
+	other, err := bgfparser.ParseTXT("test/2025-11-04/02_NDT_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if en.Equal(other) {
+		t.Error("Expected different positions not to be equal")
+	}
+}
+
+func TestParseTXT_XGIDBar(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/2025-11-04/04_DP_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if pos.OnBar["X"] != 1 {
+		t.Errorf("OnBar[X] = %d, want 1", pos.OnBar["X"])
+	}
+	if pos.OnBar["O"] != 0 {
+		t.Errorf("OnBar[O] = %d, want 0", pos.OnBar["O"])
+	}
+}
+
+func TestPosition_AsPerspective(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	// Same player: values unchanged
+	same := pos.AsPerspective(pos.OnRoll)
+	if same.Evaluations[0].Equity != pos.Evaluations[0].Equity {
+		t.Errorf("Equity changed for the player on roll: %v", same.Evaluations[0].Equity)
+	}
+
+	other := pos.AsPerspective("O")
+	if other.Board != pos.Board {
+		t.Error("Board must not change")
+	}
+	for i, e := range other.Evaluations {
+		orig := pos.Evaluations[i]
+		if e.Equity != -orig.Equity || e.Diff != -orig.Diff {
+			t.Errorf("Evaluation %d: Equity/Diff = %v/%v, want %v/%v", i, e.Equity, e.Diff, -orig.Equity, -orig.Diff)
+		}
+		if math.Abs(e.Win-(1-orig.Win)) > 1e-9 {
+			t.Errorf("Evaluation %d: Win = %v, want %v", i, e.Win, 1-orig.Win)
+		}
+		if e.WinG != orig.LoseG || e.LoseG != orig.WinG || e.WinBG != orig.LoseBG || e.LoseBG != orig.WinBG {
+			t.Errorf("Evaluation %d: gammon chances not swapped: %+v", i, e)
+		}
+	}
+
+	// The original position must be left untouched
+	if pos.Evaluations[0].Equity != -0.492 {
+		t.Errorf("Original equity modified: %v", pos.Evaluations[0].Equity)
+	}
+}
+
+func TestPosition_AsPerspectiveCube(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/2025-11-04/03_DT_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if len(pos.CubeDecisions) == 0 {
+		t.Fatal("Expected cube decisions")
+	}
+
+	other := pos.AsPerspective(map[string]string{"X": "O", "O": "X"}[pos.OnRoll])
+	for i, d := range other.CubeDecisions {
+		orig := pos.CubeDecisions[i]
+		if math.Abs(d.MWC-(1-orig.MWC)) > 1e-9 || d.EMG != -orig.EMG || d.EMGDiff != -orig.EMGDiff {
+			t.Errorf("Decision %q not inverted: %+v from %+v", d.Action, d, orig)
+		}
+	}
+}
+
+func TestPosition_Normalize(t *testing.T) {
+	pos := &bgfparser.Position{
+		PlayerX:   "  Red ",
+		PlayerO:   "Green\t",
+		Dice:      [2]int{2, 5},
+		CubeOwner: "center",
+	}
+
+	pos.Normalize()
+
+	if pos.Dice != [2]int{5, 2} {
+		t.Errorf("Dice = %v, want [5 2]", pos.Dice)
+	}
+	if pos.OnBar == nil || pos.PipCount == nil {
+		t.Error("OnBar and PipCount maps must be initialized")
+	}
+	if pos.PlayerX != "Red" || pos.PlayerO != "Green" {
+		t.Errorf("Players = %q / %q, want Red / Green", pos.PlayerX, pos.PlayerO)
+	}
+	if pos.CubeOwner != "" {
+		t.Errorf("CubeOwner = %q, want empty for a centered cube", pos.CubeOwner)
+	}
+
+	// Normalized positions serialize identically regardless of dice order
+	other := &bgfparser.Position{PlayerX: "Red", PlayerO: "Green", Dice: [2]int{5, 2}}
+	other.Normalize()
+	a, _ := pos.ToJSON()
+	b, _ := other.ToJSON()
+	if string(a) != string(b) {
+		t.Errorf("Normalized JSON differs:\n%s\n%s", a, b)
+	}
+}
+
+func TestPosition_HomeBoardAndBorneOff(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/bearoff_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	tests := []struct {
+		player string
+		home   [6]int
+		off    int
+	}{
+		{"X", [6]int{2, 3, 1, 0, 2, 1}, 6},
+		{"O", [6]int{1, 0, 2, 2, 3, 0}, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.player, func(t *testing.T) {
+			home := pos.HomeBoard(tt.player)
+			if home != tt.home {
+				t.Errorf("HomeBoard(%s) = %v, want %v", tt.player, home, tt.home)
+			}
+
+			off := pos.BorneOff(tt.player)
+			if off != tt.off {
+				t.Errorf("BorneOff(%s) = %d, want %d", tt.player, off, tt.off)
+			}
+
+			total := off
+			for _, n := range home {
+				total += n
+			}
+			if total != 15 {
+				t.Errorf("Home checkers plus borne off = %d, want 15", total)
+			}
+		})
+	}
+}
+
+func TestAllRolls(t *testing.T) {
+	rolls := bgfparser.AllRolls()
+	if len(rolls) != 21 {
+		t.Fatalf("Got %d rolls, want 21", len(rolls))
+	}
+
+	total := 0
+	seen := make(map[[2]int]bool)
+	for _, roll := range rolls {
+		if roll[0] < roll[1] {
+			t.Errorf("Roll %v not ordered higher die first", roll)
+		}
+		if seen[roll] {
+			t.Errorf("Duplicate roll %v", roll)
+		}
+		seen[roll] = true
+		total += bgfparser.RollWeight(roll)
+	}
+	if total != 36 {
+		t.Errorf("Weights sum to %d, want 36", total)
+	}
+
+	if w := bgfparser.RollWeight([2]int{0, 3}); w != 0 {
+		t.Errorf("RollWeight of invalid dice = %d, want 0", w)
+	}
+}
+
+func TestDice(t *testing.T) {
+	tests := []struct {
+		dice   bgfparser.Dice
+		valid  bool
+		double bool
+		str    string
+	}{
+		{bgfparser.Dice{3, 1}, true, false, "3-1"},
+		{bgfparser.Dice{5, 5}, true, true, "5-5"},
+		{bgfparser.Dice{0, 0}, false, false, "0-0"},
+		{bgfparser.Dice{7, 7}, false, false, "7-7"},
+		{bgfparser.Dice{6, 0}, false, false, "6-0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			if got := tt.dice.Valid(); got != tt.valid {
+				t.Errorf("Valid() = %v, want %v", got, tt.valid)
+			}
+			if got := tt.dice.IsDouble(); got != tt.double {
+				t.Errorf("IsDouble() = %v, want %v", got, tt.double)
+			}
+			if got := tt.dice.String(); got != tt.str {
+				t.Errorf("String() = %q, want %q", got, tt.str)
+			}
+		})
+	}
+}
+
+func TestDice_JSON(t *testing.T) {
+	pos := &bgfparser.Position{Dice: bgfparser.Dice{3, 1}}
+	data, err := json.Marshal(pos)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"dice":[3,1]`) {
+		t.Errorf("Marshal = %s, want dice as [3,1]", data)
+	}
+
+	// Positions serialized with [2]int dice read back unchanged
+	var legacy struct {
+		Dice [2]int `json:"dice"`
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil || legacy.Dice != [2]int{3, 1} {
+		t.Errorf("Unmarshal into [2]int = %v, %v", legacy.Dice, err)
+	}
+	var back bgfparser.Position
+	if err := json.Unmarshal([]byte(`{"dice":[6,5]}`), &back); err != nil || back.Dice != (bgfparser.Dice{6, 5}) {
+		t.Errorf("Unmarshal = %v, %v, want 6-5", back.Dice, err)
+	}
+	if err := json.Unmarshal([]byte(`{"dice":"4-2"}`), &back); err != nil || back.Dice != (bgfparser.Dice{4, 2}) {
+		t.Errorf("Unmarshal of a string = %v, %v, want 4-2", back.Dice, err)
+	}
+	if err := json.Unmarshal([]byte(`{"dice":"four"}`), &back); err == nil {
+		t.Error("Expected an error for malformed dice")
+	}
+}
+
+func TestPosition_ToGnuBgID(t *testing.T) {
+	files := []string{
+		"test/2025-11-04/01_checkerPosition_EN.txt",
+		"test/2025-11-04/02_NDT_EN.txt",
+		"test/2025-11-04/03_DT_EN.txt",
+		"test/2025-11-04/04_DP_EN.txt",
+		"test/2025-11-04/05_NRT_EN.txt",
+		"test/2025-11-04/06_RT_EN.txt",
+		"test/2025-11-04/07_RP_EN.txt",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXT(file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+			want := pos.PositionID + ":" + pos.MatchID
+			if got := pos.ToGnuBgID(); got != want {
+				t.Errorf("ToGnuBgID() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPosition_AwayScores(t *testing.T) {
+	tests := []struct {
+		name  string
+		xgid  string
+		xAway int
+		oAway int
+		want  string
+	}{
+		{"Match start", "-b----E-C---eE---c-e----B-:0:0:1:00:0:0:0:7:10", 7, 7, "X is 7-away, O is 7-away"},
+		{"Mid match", "-b----E-C---eE---c-e----B-:0:0:1:00:4:2:0:7:10", 3, 5, "X is 3-away, O is 5-away"},
+		{"Crawford game", "-b----E-C---eE---c-e----B-:0:0:1:00:6:3:1:7:10", 1, 4, "X is 1-away, O is 4-away (Crawford)"},
+		{"Post-Crawford", "-b----E-C---eE---c-e----B-:0:0:1:00:3:8:0:9:10", 6, 1, "X is 6-away, O is 1-away (post-Crawford)"},
+		{"Money game", "-b----E-C---eE---c-e----B-:0:0:1:00:0:0:0:0:10", 0, 0, "Money game"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := parseXGIDPosition(t, tt.xgid)
+			xAway, oAway := pos.AwayScores()
+			if xAway != tt.xAway || oAway != tt.oAway {
+				t.Errorf("AwayScores() = %d, %d, want %d, %d", xAway, oAway, tt.xAway, tt.oAway)
+			}
+			if got := pos.ScoreString(); got != tt.want {
+				t.Errorf("ScoreString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseXGID(t *testing.T) {
+	files := []string{
+		"test/2025-11-04/01_checkerPosition_EN.txt",
+		"test/2025-11-04/03_DT_EN.txt",
+		"test/2025-11-04/04_DP_EN.txt",
+		"test/2025-11-04/05_NRT_EN.txt",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			want, err := bgfparser.ParseTXT(file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+			got, err := bgfparser.ParseXGID(want.XGID)
+			if err != nil {
+				t.Fatalf("ParseXGID failed: %v", err)
+			}
+
+			if got.Board != want.Board {
+				t.Errorf("Board = %v, want %v", got.Board, want.Board)
+			}
+			if got.CubeValue != want.CubeValue || got.CubeOwner != want.CubeOwner {
+				t.Errorf("Cube = %d/%q, want %d/%q", got.CubeValue, got.CubeOwner, want.CubeValue, want.CubeOwner)
+			}
+			if got.OnRoll != want.OnRoll {
+				t.Errorf("OnRoll = %q, want %q", got.OnRoll, want.OnRoll)
+			}
+			// The XGID writes the higher die first
+			if got.Dice != want.Dice && got.Dice != [2]int{want.Dice[1], want.Dice[0]} {
+				t.Errorf("Dice = %v, want %v", got.Dice, want.Dice)
+			}
+			if got.ScoreX != want.ScoreX || got.ScoreO != want.ScoreO || got.MatchLength != want.MatchLength {
+				t.Errorf("Score = %d-%d/%d, want %d-%d/%d", got.ScoreX, got.ScoreO, got.MatchLength, want.ScoreX, want.ScoreO, want.MatchLength)
+			}
+			if got.Crawford != want.Crawford || got.PostCrawford != want.PostCrawford {
+				t.Errorf("Crawford/PostCrawford = %v/%v, want %v/%v", got.Crawford, got.PostCrawford, want.Crawford, want.PostCrawford)
+			}
+			for _, player := range []string{"X", "O"} {
+				if got.OnBar[player] != want.OnBar[player] || got.Off[player] != want.Off[player] || got.PipCount[player] != want.PipCount[player] {
+					t.Errorf("%s: bar/off/pips = %d/%d/%d, want %d/%d/%d", player,
+						got.OnBar[player], got.Off[player], got.PipCount[player],
+						want.OnBar[player], want.Off[player], want.PipCount[player])
+				}
+			}
+			if got.ToXGID() != want.XGID {
+				t.Errorf("ToXGID() = %q, want %q", got.ToXGID(), want.XGID)
+			}
+		})
+	}
+}
+
+func TestParseXGID_Malformed(t *testing.T) {
+	tests := []string{
+		"",
+		"-b----E-C---eE---c-e----B-",
+		"-b----E-C---eE---c-e----B-:0:0:1:00:0:0:0",
+		"-b----E-C---eE---c-e----B-:0:0:1:00:0:0:0:7",
+		"-b----E-C---eE---c-e----B-:0:0:1:00:0:0:0:7:10:1",
+		"-b----E-C---eE---c-e----B-:x:0:1:00:0:0:0:7:10",
+		"-b----E-C---eE---c-e----B-:0:2:1:00:0:0:0:7:10",
+		"-b----E-C---eE---c-e----B-:0:0:0:00:0:0:0:7:10",
+		"-b----E-C---eE---c-e----B-:0:0:1:70:0:0:0:7:10",
+		"-b----E-C---eE---c-e----B-:0:0:1:30:0:0:0:7:10",
+		"-b----E-C---eE---c-e----B-:0:0:1:00:-1:0:0:7:10",
+		"-b----E-C---eE---c-e---B-:0:0:1:00:0:0:0:7:10",
+		"-b----E-C---eE---c-e----Z-:0:0:1:00:0:0:0:7:10",
+	}
+
+	for _, xgid := range tests {
+		if _, err := bgfparser.ParseXGID(xgid); err == nil {
+			t.Errorf("ParseXGID(%q) expected error", xgid)
+		}
+	}
+}
+
+func TestParseXGID_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		xgid string
+		want string // Error substring, "" when valid
+	}{
+		{"Valid", "-b----E-C---eE---c-e----B-:1:1:-1:52:2:4:0:7:10", ""},
+		{"Four fields", "-b----E-C---eE---c-e----B-:0:0:1", "expected 10 fields, got 4"},
+		{"Non-numeric score", "-b----E-C---eE---c-e----B-:0:0:1:00:two:0:0:7:10", `field 6 is not a number: "two"`},
+		{"Three-digit dice", "-b----E-C---eE---c-e----B-:0:0:1:521:0:0:0:7:10", "invalid dice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, err := bgfparser.ParseXGID(tt.xgid)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("ParseXGID failed: %v", err)
+				}
+				if pos.CubeValue != 2 || pos.CubeOwner != "X" || pos.OnRoll != "O" || pos.Dice != (bgfparser.Dice{5, 2}) ||
+					pos.ScoreX != 2 || pos.ScoreO != 4 || pos.MatchLength != 7 {
+					t.Errorf("ParseXGID = %+v, want cube 2 owned by X, O on roll with 5-2 at 2-4 of 7", pos)
+				}
+				return
+			}
+
+			var parseErr *bgfparser.ParseError
+			if !errors.As(err, &parseErr) || !strings.Contains(parseErr.Message, tt.want) {
+				t.Errorf("ParseXGID error = %v, want a ParseError containing %q", err, tt.want)
+			}
+
+			// The TXT parser rejects the XGID line instead of parsing part of it
+			_, err = bgfparser.ParseTXTFromReader(strings.NewReader("\nXGID=" + tt.xgid + "\n"))
+			if !errors.As(err, &parseErr) || parseErr.Line != 2 || !strings.Contains(parseErr.Message, tt.want) {
+				t.Errorf("ParseTXTFromReader error = %v, want a ParseError on line 2 containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBGBlitzMatchID(t *testing.T) {
+	files, err := filepath.Glob("test/2025-11-04/*.txt")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("No TXT fixtures found: %v", err)
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXT(file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+			if pos.MatchID == "" || pos.XGID == "" {
+				t.Skip("Fixture lacks a Match-ID or XGID")
+			}
+
+			state, err := bgfparser.DecodeBGBlitzMatchID(pos.MatchID)
+			if err != nil {
+				t.Fatalf("DecodeBGBlitzMatchID failed: %v", err)
+			}
+
+			// The XGID writes the higher die first
+			dice := state.Dice
+			if dice[0] < dice[1] {
+				dice[0], dice[1] = dice[1], dice[0]
+			}
+			want, _ := bgfparser.ParseXGID(pos.XGID)
+			if state.Dice.Valid() != want.Dice.Valid() {
+				t.Errorf("Dice %v rolled = %v, want %v", state.Dice, state.Dice.Valid(), want.Dice.Valid())
+			}
+			got := bgfparser.MatchState{
+				CubeValue:   state.CubeValue,
+				CubeOwner:   state.CubeOwner,
+				OnRoll:      state.OnRoll,
+				Crawford:    state.Crawford,
+				Dice:        dice,
+				MatchLength: state.MatchLength,
+				ScoreX:      state.ScoreX,
+				ScoreO:      state.ScoreO,
+			}
+			wantState := bgfparser.MatchState{
+				CubeValue:   want.CubeValue,
+				CubeOwner:   want.CubeOwner,
+				OnRoll:      want.OnRoll,
+				Crawford:    want.Crawford,
+				Dice:        want.Dice,
+				MatchLength: want.MatchLength,
+				ScoreX:      want.ScoreX,
+				ScoreO:      want.ScoreO,
+			}
+			if got != wantState {
+				t.Errorf("Match-ID state = %+v, want %+v", got, wantState)
+			}
+		})
+	}
+
+	if _, err := bgfparser.DecodeBGBlitzMatchID("not-an-id"); err == nil {
+		t.Error("Expected error for an invalid Match-ID")
+	}
+}
+
+func TestParseTXT_MatchIDFallback(t *testing.T) {
+	// bar_off_EN.txt has a Match-ID but no XGID
+	pos, err := bgfparser.ParseTXT("test/fixtures/bar_off_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if pos.XGID != "" {
+		t.Fatal("Expected a fixture without XGID")
+	}
+	if pos.CubeValue != 2 || pos.CubeOwner != "O" {
+		t.Errorf("Cube = %d/%q, want 2/O", pos.CubeValue, pos.CubeOwner)
+	}
+	if pos.MatchLength != 7 || pos.ScoreX != 3 || pos.ScoreO != 6 || pos.OnRoll != "X" {
+		t.Errorf("Match state = %d-%d/%d, %s on roll", pos.ScoreX, pos.ScoreO, pos.MatchLength, pos.OnRoll)
+	}
+}
+
+func TestParseTXT_PositionIDMismatch(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/inconsistent_ids_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if len(pos.Warnings) != 1 || !strings.Contains(pos.Warnings[0], "different boards") {
+		t.Errorf("Warnings = %v, want a Position-ID/XGID mismatch warning", pos.Warnings)
+	}
+
+	// The XGID board is kept
+	want, err := bgfparser.ParseXGID(pos.XGID)
+	if err != nil {
+		t.Fatalf("ParseXGID failed: %v", err)
+	}
+	if pos.Board != want.Board {
+		t.Errorf("Board = %v, want the XGID board %v", pos.Board, want.Board)
+	}
+
+	consistent, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if len(consistent.Warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", consistent.Warnings)
+	}
+
+	decoded, err := bgfparser.DecodeBGBlitzPositionID(consistent.PositionID, consistent.OnRoll)
+	if err != nil {
+		t.Fatalf("DecodeBGBlitzPositionID failed: %v", err)
+	}
+	if decoded.Board != consistent.Board {
+		t.Errorf("Decoded board = %v, want %v", decoded.Board, consistent.Board)
+	}
+}
+
+func TestDiffPositions(t *testing.T) {
+	a, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	b, err := bgfparser.ParseTXT("test/fixtures/diff_checker_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	diff, err := bgfparser.DiffPositions(a, b)
+	if err != nil {
+		t.Fatalf("DiffPositions failed: %v", err)
+	}
+
+	want := []struct {
+		move         string
+		rankA, rankB int
+		delta        float64
+	}{
+		{"19/18, 14/12", 1, 2, -0.008},
+		{"19/18, 3/1", 2, 1, 0.065},
+		{"19/17, 18/17", 3, 3, 0},
+		{"14/12, 3/2", 4, 4, -0.012},
+	}
+	if len(diff.Moves) != len(want) {
+		t.Fatalf("Got %d move diffs, want %d: %+v", len(diff.Moves), len(want), diff.Moves)
+	}
+	for i, w := range want {
+		m := diff.Moves[i]
+		if m.Move != w.move || m.RankA != w.rankA || m.RankB != w.rankB || math.Abs(m.Delta-w.delta) > 1e-9 {
+			t.Errorf("Move diff %d = %+v, want %s ranks %d/%d delta %v", i, m, w.move, w.rankA, w.rankB, w.delta)
+		}
+	}
+	if len(diff.OnlyInA) != 1 || diff.OnlyInA[0] != "14/11" || len(diff.OnlyInB) != 0 {
+		t.Errorf("OnlyInA = %v, OnlyInB = %v, want [14/11] and []", diff.OnlyInA, diff.OnlyInB)
+	}
+	if !diff.BestMoveChanged || diff.BestMoveA != "19/18, 14/12" || diff.BestMoveB != "19/18, 3/1" {
+		t.Errorf("Best moves = %q -> %q (changed %v)", diff.BestMoveA, diff.BestMoveB, diff.BestMoveChanged)
+	}
+
+	// Cube decisions
+	a, err = bgfparser.ParseTXT("test/2025-11-04/03_DT_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	b, err = bgfparser.ParseTXT("test/fixtures/diff_cube_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	diff, err = bgfparser.DiffPositions(a, b)
+	if err != nil {
+		t.Fatalf("DiffPositions failed: %v", err)
+	}
+	if !diff.CubeDisagreement || diff.BestCubeActionA != "Double / Take" || diff.BestCubeActionB != "No Double" {
+		t.Errorf("Best cube actions = %q -> %q (disagreement %v)", diff.BestCubeActionA, diff.BestCubeActionB, diff.CubeDisagreement)
+	}
+	wantCube := map[string][2]float64{
+		"Double / Take": {-0.005, -0.015},
+		"No Double":     {0.008, 0.015},
+		"Double / Pass": {0, 0},
+	}
+	if len(diff.CubeDecisions) != len(wantCube) {
+		t.Fatalf("Got %d cube decision diffs, want %d", len(diff.CubeDecisions), len(wantCube))
+	}
+	for _, d := range diff.CubeDecisions {
+		w := wantCube[d.Action]
+		if math.Abs(d.MWCDelta-w[0]) > 1e-9 || math.Abs(d.EMGDelta-w[1]) > 1e-9 {
+			t.Errorf("%s: deltas = %v/%v, want %v/%v", d.Action, d.MWCDelta, d.EMGDelta, w[0], w[1])
+		}
+	}
+
+	// A different board is an error
+	if _, err := bgfparser.DiffPositions(a, parseXGIDPosition(t, "-B-CBBB---a---A---ABcbbbd-:1:-1:1:21:3:6:0:7:10")); err == nil {
+		t.Error("Expected an error diffing different positions")
+	}
+}
+
+func TestPosition_ToBoardASCII(t *testing.T) {
+	// The drawing matches the board of a BGBlitz export exactly
+	for _, file := range []string{"test/fixtures/checker_and_cube_EN.txt", "test/2025-11-04/04_DP_EN.txt"} {
+		pos, err := bgfparser.ParseTXT(file)
+		if err != nil {
+			t.Fatalf("ParseTXT(%s) failed: %v", file, err)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.SplitAfter(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+		if want := strings.Join(lines[:13], ""); pos.ToBoardASCII() != want {
+			t.Errorf("%s: ToBoardASCII() =\n%s\nwant\n%s", file, pos.ToBoardASCII(), want)
+		}
+	}
+
+	// Without an XGID, parsing the drawing gives back the board
+	for _, xgid := range []string{
+		"-b----E-C---eE---c-e----B-:0:0:1:00:0:0:0:7:10",
+		"--BaBCCBAA------acccc-a--A:0:0:1:00:0:2:0:7:10",
+		"bO----------------------oB:6:1:1:00:0:0:0:0:10",
+		"cBBBBBB-----------------aC:2:-1:-1:00:3:1:0:5:10",
+	} {
+		pos, err := bgfparser.ParseXGID(xgid)
+		if err != nil {
+			t.Fatalf("ParseXGID(%q) failed: %v", xgid, err)
+		}
+		drawing := pos.ToBoardASCII()
+		parsed, err := bgfparser.ParseTXTFromReader(strings.NewReader(drawing))
+		if err != nil {
+			t.Fatalf("ParseTXTFromReader failed: %v", err)
+		}
+		if parsed.Board != pos.Board || parsed.OnBar["X"] != pos.OnBar["X"] || parsed.OnBar["O"] != pos.OnBar["O"] {
+			t.Errorf("%s: round trip gave board %v bar %v, want %v bar %v\n%s",
+				xgid, parsed.Board, parsed.OnBar, pos.Board, pos.OnBar, drawing)
+		}
+	}
+}
+
+func TestPosition_ToFIBSBoard(t *testing.T) {
+	pos, err := bgfparser.ParseXGID("-b----E-C---eE---c-e----B-:0:0:-1:62:0:0:0:3:10")
+	if err != nil {
+		t.Fatalf("ParseXGID failed: %v", err)
+	}
+	pos.PlayerO = "You"
+	pos.PlayerX = "someplayer"
+
+	// The opening position example from the FIBS client protocol, except
+	// for the may-double flags: neither player may double once O has rolled
+	want := "board:You:someplayer:3:0:0:" +
+		"0:-2:0:0:0:0:5:0:3:0:0:0:-5:5:0:0:0:-3:0:-5:0:0:0:0:2:0:" +
+		"1:6:2:0:0:1:0:0:0:1:-1:0:25:0:0:0:0:2:0:0:0"
+	if got := pos.ToFIBSBoard("O"); got != want {
+		t.Errorf("ToFIBSBoard(O) =\n%s\nwant\n%s", got, want)
+	}
+
+	// Seen by X, the same opening layout has X's color and O's dice
+	want = "board:someplayer:You:3:0:0:" +
+		"0:2:0:0:0:0:-5:0:-3:0:0:0:5:-5:0:0:0:3:0:5:0:0:0:0:-2:0:" +
+		"1:0:0:6:2:1:0:0:0:-1:-1:0:25:0:0:0:0:0:0:0:0"
+	if got := pos.ToFIBSBoard("X"); got != want {
+		t.Errorf("ToFIBSBoard(X) =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNewPosition_Builder(t *testing.T) {
+	var board [26]int
+	board[24], board[13], board[8], board[6] = 2, 5, 3, 5
+	board[1], board[12], board[17], board[19] = -2, -5, -3, -4
+	board[0] = -1 // One O checker on the bar
+
+	pos := bgfparser.NewPosition().
+		WithBoard(board).
+		WithOnRoll("X").
+		WithDice(3, 1).
+		WithCube(2, "O").
+		WithMatchLength(7).
+		WithScore(2, 4)
+
+	if pos.OnBar["O"] != 1 || pos.OnBar["X"] != 0 || pos.Board[0] != 0 {
+		t.Errorf("OnBar = %v, Board[0] = %d, want one O checker on the bar", pos.OnBar, pos.Board[0])
+	}
+	if pos.PipCount["X"] != 167 || pos.PipCount["O"] != 167-6+25 {
+		t.Errorf("PipCount = %v, want X:167 O:186", pos.PipCount)
+	}
+	if pos.Off["X"] != 0 || pos.Off["O"] != 0 {
+		t.Errorf("Off = %v, want none", pos.Off)
+	}
+
+	xgid := pos.ToXGID()
+	parsed, err := bgfparser.ParseXGID(xgid)
+	if err != nil {
+		t.Fatalf("ParseXGID(%q) failed: %v", xgid, err)
+	}
+	if !parsed.Equal(pos) {
+		t.Errorf("Round trip changed the position: %q -> %q", pos.CanonicalKey(), parsed.CanonicalKey())
+	}
+	if parsed.Board != pos.Board || parsed.OnBar["O"] != 1 || parsed.Dice != [2]int{3, 1} ||
+		parsed.CubeValue != 2 || parsed.CubeOwner != "O" || parsed.ScoreX != 2 || parsed.ScoreO != 4 || parsed.MatchLength != 7 {
+		t.Errorf("ParseXGID(%q) = %+v", xgid, parsed)
+	}
+
+	// An empty position has every checker borne off
+	empty := bgfparser.NewPosition()
+	if empty.CubeValue != 1 || empty.Off["X"] != 15 || empty.OnBar == nil || empty.PipCount == nil {
+		t.Errorf("NewPosition() = %+v", empty)
+	}
+
+	// The setters create the maps of a zero Position
+	zero := (&bgfparser.Position{}).WithBoard(board).WithOnRoll("X").WithScore(2, 4)
+	if zero.OnBar["O"] != 1 || zero.PipCount["X"] != 167 || zero.Off["X"] != 0 {
+		t.Errorf("Zero Position with board = %+v", zero)
+	}
+	for _, p := range []*bgfparser.Position{
+		(&bgfparser.Position{}).WithOnRoll("O"),
+		(&bgfparser.Position{}).WithScore(1, 0),
+		(&bgfparser.Position{}).WithDice(6, 5),
+		(&bgfparser.Position{}).WithCube(2, "X"),
+		(&bgfparser.Position{}).WithMatchLength(5),
+	} {
+		if p.OnBar == nil || p.PipCount == nil || p.Off == nil {
+			t.Errorf("Setter left nil maps: %+v", p)
+		}
+	}
+}
+
+func TestPosition_CubeIsCentered(t *testing.T) {
+	// Opening position of a fresh game
+	const opening = "-b----E-C---eE---c-e----B-:0:0:1:00:0:0:0:5:10"
+	pos, err := bgfparser.ParseXGID(opening)
+	if err != nil {
+		t.Fatalf("ParseXGID failed: %v", err)
+	}
+	if pos.CubeValue != 1 || pos.CubeOwner != "" || !pos.CubeIsCentered() {
+		t.Errorf("Cube = %d/%q centered %v, want 1/\"\" centered", pos.CubeValue, pos.CubeOwner, pos.CubeIsCentered())
+	}
+
+	// An undoubled cube is centered even when an owner is given
+	for _, p := range []*bgfparser.Position{
+		parseXGIDPosition(t, "-b----E-C---eE---c-e----B-:0:1:1:00:0:0:0:5:10"),
+		bgfparser.NewPosition().WithCube(1, "X"),
+	} {
+		if p.CubeOwner != "" || !p.CubeIsCentered() {
+			t.Errorf("Cube = %d/%q, want centered", p.CubeValue, p.CubeOwner)
+		}
+		for _, player := range []string{"X", "O"} {
+			p.OnRoll = player
+			if !p.CanDouble(player) {
+				t.Errorf("%s should be able to double a centered cube", player)
+			}
+		}
+	}
+	if xgid := (&bgfparser.Position{CubeValue: 1, CubeOwner: "O", OnRoll: "X"}).ToXGID(); !strings.HasPrefix(xgid, "--------------------------:0:0:") {
+		t.Errorf("ToXGID = %s, want a centered cube", xgid)
+	}
+
+	// A doubled cube keeps its owner
+	owned := parseXGIDPosition(t, "-b----E-C---eE---c-e----B-:1:-1:1:00:0:0:0:5:10")
+	if owned.CubeValue != 2 || owned.CubeOwner != "O" || owned.CubeIsCentered() {
+		t.Errorf("Cube = %d/%q, want 2/O not centered", owned.CubeValue, owned.CubeOwner)
+	}
+	if owned.CanDouble("X") {
+		t.Error("X should not be able to double an O-owned cube")
+	}
+	if owned.OnRoll = "O"; !owned.CanDouble("O") {
+		t.Error("O should be able to double an O-owned cube")
+	}
+}
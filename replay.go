@@ -0,0 +1,74 @@
+package bgfparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// openingBoard is the starting position, laid out like Position.Board
+var openingBoard = [26]int{
+	0,
+	-2, 0, 0, 0, 0, 5, 0, 3, 0, 0, 0, -5,
+	5, 0, 0, 0, -3, 0, -5, 0, 0, 0, 0, 2,
+	0,
+}
+
+// bgfPlayer returns the position player of a BGF player or color: Green (1)
+// is O and Red (-1) is X, as in BGBlitz TXT exports
+func bgfPlayer(player int) string {
+	if player > 0 {
+		return "O"
+	}
+	return "X"
+}
+
+// FinalPositions returns the position at the end of each game, replaying
+// its checker plays from the opening position with Green as player O and
+// Red as player X. Each position holds the score at the end of the game and
+// its Winner; the player on roll is the one who would have played next.
+// It fails when the match has no games or a play does not fit the board.
+func (m *Match) FinalPositions() ([]*Position, error) {
+	summaries, err := m.GameSummaries()
+	if err != nil {
+		return nil, err
+	}
+
+	info := m.GetMatchInfo()
+	green, _ := info["playerGreen"].(string)
+	red, _ := info["playerRed"].(string)
+	matchLength, _ := info["matchLength"].(int)
+
+	games := m.Games()
+	positions := make([]*Position, len(games))
+	for i, game := range games {
+		pos := NewPosition().WithBoard(openingBoard)
+		for j, move := range game.Moves {
+			if !strings.EqualFold(move.Type, "amove") || len(move.Moves) == 0 {
+				continue
+			}
+			pos.OnRoll = bgfPlayer(move.Player)
+			pos.Dice = Dice(move.Dice)
+			if !pos.Dice.Valid() {
+				pos.Dice = Dice{}
+			}
+			next, err := pos.ApplyMoves(move.Moves)
+			if err != nil {
+				return nil, fmt.Errorf("game %d, move %d: %v", i+1, j+1, err)
+			}
+			pos = next
+		}
+
+		summary := summaries[i]
+		pos.PlayerX, pos.PlayerO = red, green
+		pos.Winner = bgfPlayer(1)
+		if summary.Winner == "red" {
+			pos.Winner = bgfPlayer(-1)
+		}
+		for _, player := range []string{"X", "O"} {
+			pos.Off[player] = pos.BorneOff(player)
+		}
+		positions[i] = pos.WithMatchLength(matchLength).WithScore(summary.ScoreRed, summary.ScoreGreen)
+	}
+
+	return positions, nil
+}
@@ -1,10 +1,16 @@
 package bgfparser
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // ParseTXT parses a BGBlitz position text file from disk
@@ -29,72 +35,240 @@ func ParseTXT(filename string) (*Position, error) {
 	return pos, nil
 }
 
-// parseBoard extracts checker positions from board lines
+// Parser parses TXT position files repeatedly, e.g. on every edit in an
+// editor. Patterns are compiled once per program and the line buffer is
+// reused across calls. A Parser must not be used concurrently.
+type Parser struct {
+	Options TXTOptions
+
+	buf []byte
+}
+
+// NewParser returns a Parser using the given options
+func NewParser(opts TXTOptions) *Parser {
+	return &Parser{Options: opts}
+}
+
+// ParseTXT parses a BGBlitz TXT position file from r like ParseTXTFromReaderWithOptions
+func (p *Parser) ParseTXT(r io.Reader) (*Position, error) {
+	if p.buf == nil {
+		maxLine := p.Options.MaxLineLength
+		if maxLine <= 0 {
+			maxLine = DefaultMaxLineLength
+		}
+		p.buf = make([]byte, 0, min(maxLine, bufio.MaxScanTokenSize))
+	}
+	return parseTXTReader(r, p.Options, p.buf)
+}
+
+// scanTXTLines is a bufio.SplitFunc like bufio.ScanLines that also ends
+// lines at a lone "\r", as in files saved with classic Mac line endings.
+// "\r\n" still counts as a single line ending.
+func scanTXTLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		// A "\r" ending the buffer may be followed by "\n"
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// parseBoard reads checker positions from the ASCII board lines, laid out
+// as ToBoardASCII draws them. The XGID is more reliable, so the board is
+// only read from the drawing in files without one.
 func parseBoard(pos *Position, lines []string) {
-	// Note: Board is already parsed from XGID if available
-	// This function could parse the ASCII art board representation
-	// but for now we rely on XGID parsing which is more accurate
+	if pos.XGID != "" {
+		return
+	}
 
-	// Only try to parse checkers on bar from ASCII art if not already set
+	type cell struct {
+		player string
+		count  int // checkers drawn
+		stack  int // count printed for a tall stack
+	}
+	var halves [2][13]cell
+	half, seenBar, found := 0, false, false
 	for _, line := range lines {
-		if strings.Contains(line, "BAR") && (strings.Contains(line, "X") || strings.Contains(line, "O")) {
-			// Check for checkers on bar (if not already parsed from XGID)
-			parts := strings.Split(line, "BAR")
-			if len(parts) > 0 && pos.OnBar["X"] == 0 && pos.OnBar["O"] == 0 {
-				if strings.Count(parts[0], "X") > strings.Count(parts[0], "O") {
-					pos.OnBar["X"]++
-				} else if strings.Count(parts[0], "O") > strings.Count(parts[0], "X") {
-					pos.OnBar["O"]++
+		if strings.Contains(line, "BAR") {
+			half, seenBar = 1, true
+			continue
+		}
+		// Columns are counted from the left border of the board
+		line = blankBoardDice(line)
+		offset := strings.Index(line, "|") - 1
+		if offset < -1 {
+			continue
+		}
+		for c, col := range boardCellColumns {
+			from, to := offset+col-1, offset+col+2
+			if to > len(line) {
+				break
+			}
+			switch text := strings.TrimSpace(line[from:to]); text {
+			case "":
+			case "X", "O":
+				halves[half][c].player = text
+				halves[half][c].count++
+				found = true
+			default:
+				// The count of a tall stack replaces its drawn checkers
+				if n, err := strconv.Atoi(text); err == nil {
+					halves[half][c].stack = n
+				}
+			}
+		}
+	}
+	if !seenBar || !found {
+		return
+	}
+
+	// An explicit "Bar:" line takes precedence over the drawing
+	fillBar := pos.OnBar["X"] == 0 && pos.OnBar["O"] == 0
+	pos.Board = [26]int{}
+	for h, points := range [2][13]int{topBoardPoints, bottomBoardPoints} {
+		for c, point := range points {
+			cell := halves[h][c]
+			if cell.stack > 0 {
+				cell.count = cell.stack
+			}
+			switch {
+			case cell.player == "":
+			case point == 0:
+				if fillBar {
+					pos.OnBar[cell.player] += cell.count
 				}
+			default:
+				pos.Board[point] = playerSign(cell.player) * cell.count
 			}
 		}
 	}
 }
 
-// parseXGID extracts information from XGID format
-func parseXGID(pos *Position, xgid string) {
-	// XGID format: board:cubeValue:cubeOwner:onRoll:dice:crawford:score1:score2:matchLength:turn
-	parts := strings.Split(xgid, ":")
-	if len(parts) >= 5 {
-		// Parse board position from first part
-		parseXGIDBoard(pos, parts[0])
-
-		// Parse cube value
-		if val, err := strconv.Atoi(parts[1]); err == nil {
-			pos.CubeValue = 1 << val // Cube value is 2^n
-		}
-		// Parse cube owner
-		switch parts[2] {
-		case "1":
-			pos.CubeOwner = "X"
-		case "-1":
-			pos.CubeOwner = "O"
-		default:
-			pos.CubeOwner = ""
-		}
-		// Parse on roll
-		switch parts[3] {
-		case "1":
-			pos.OnRoll = "X"
-		case "-1":
-			pos.OnRoll = "O"
+// boardDiceRe matches rolled dice drawn on the board, as die faces ("⚄ ⚁")
+// or in brackets ("[5][2]")
+var boardDiceRe = regexp.MustCompile(`([⚀-⚅])\s*([⚀-⚅])|\[([1-6])\]\s*\[([1-6])\]`)
+
+// parseBoardDice sets the dice from those drawn on the board lines
+func parseBoardDice(pos *Position, lines []string) {
+	for _, line := range lines {
+		m := boardDiceRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
 		}
+		if m[1] != "" {
+			// Die faces follow each other from U+2680 (one) to U+2685 (six)
+			first, _ := utf8.DecodeRuneInString(m[1])
+			second, _ := utf8.DecodeRuneInString(m[2])
+			pos.Dice = Dice{int(first-'⚀') + 1, int(second-'⚀') + 1}
+		} else {
+			pos.Dice[0], _ = strconv.Atoi(m[3])
+			pos.Dice[1], _ = strconv.Atoi(m[4])
+		}
+		return
+	}
+}
+
+// blankBoardDice replaces dice drawn on a board line with one space per
+// column they take, so that the checker columns line up again
+func blankBoardDice(line string) string {
+	return boardDiceRe.ReplaceAllStringFunc(line, func(dice string) string {
+		return strings.Repeat(" ", utf8.RuneCountInString(dice))
+	})
+}
+
+// parseXGID extracts information from XGID format, after checking it with validateXGID
+func parseXGID(pos *Position, xgid string) error {
+	// XGID format: board:cubeValue:cubeOwner:onRoll:dice:scoreX:scoreO:crawford:matchLength:maxCube
+	fields, err := validateXGID(xgid)
+	if err != nil {
+		return err
 	}
+
+	// Parse board position from first part
+	if err := parseXGIDBoard(pos, strings.SplitN(xgid, ":", 2)[0]); err != nil {
+		return err
+	}
+
+	pos.CubeValue = 1 << fields[1] // Cube value is 2^n
+	// Parse cube owner (a cube still at 1 is centered whatever the field says)
+	switch {
+	case pos.CubeValue > 1 && fields[2] == 1:
+		pos.CubeOwner = "X"
+	case pos.CubeValue > 1 && fields[2] == -1:
+		pos.CubeOwner = "O"
+	default:
+		pos.CubeOwner = ""
+	}
+	pos.OnRoll = "X"
+	if fields[3] == -1 {
+		pos.OnRoll = "O"
+	}
+
+	// Parse score and match length
+	pos.ScoreX, pos.ScoreO, pos.MatchLength = fields[5], fields[6], fields[8]
+
+	// In match play the flag marks the Crawford game, in money play the Jacoby rule
+	if pos.MatchLength > 0 {
+		pos.Crawford = fields[7] == 1
+	} else {
+		pos.Rules.Jacoby = pos.Rules.Jacoby || fields[7]&1 == 1
+	}
+	return nil
+}
+
+// updateCrawfordState derives PostCrawford from the score once parsing is complete.
+// A side sitting at match point outside of the Crawford game means the Crawford
+// game has already been played.
+func updateCrawfordState(pos *Position) {
+	if pos.MatchLength <= 0 || pos.Crawford {
+		pos.PostCrawford = false
+		return
+	}
+	matchPoint := pos.MatchLength - 1
+	pos.PostCrawford = pos.ScoreX == matchPoint || pos.ScoreO == matchPoint
 }
 
 // parseXGIDBoard decodes the board position from XGID format
 // XGID board encoding format (26 characters):
 //
-//	Character 0: X's checkers on bar
+//	Character 0: O's checkers on bar
 //	Characters 1-24: Points 1, 2, 3, ..., 23, 24 (from X's perspective)
-//	Character 25: X's checkers borne off
+//	Character 25: X's checkers on bar
 //
 // Each character represents:
 //
 //	'-' = empty point
 //	'A'-'O' (uppercase) = 1-15 X checkers
 //	'a'-'o' (lowercase) = 1-15 O checkers
-func parseXGIDBoard(pos *Position, boardStr string) {
+func parseXGIDBoard(pos *Position, boardStr string) error {
+	// Validate before touching the board so a malformed XGID leaves it unchanged
+	runes := []rune(boardStr)
+	if len(runes) != 26 {
+		return fmt.Errorf("invalid XGID board %q: expected 26 characters, got %d", boardStr, len(runes))
+	}
+	for i, r := range runes {
+		if r != '-' && !(r >= 'A' && r <= 'O') && !(r >= 'a' && r <= 'o') {
+			return fmt.Errorf("invalid XGID board %q: unexpected character %q at position %d", boardStr, r, i)
+		}
+	}
+
 	// Initialize board
 	for i := range pos.Board {
 		pos.Board[i] = 0
@@ -102,11 +276,7 @@ func parseXGIDBoard(pos *Position, boardStr string) {
 	pos.OnBar["X"] = 0
 	pos.OnBar["O"] = 0
 
-	if len(boardStr) < 26 {
-		return // Invalid XGID
-	}
-
-	// Character 0: X's bar
+	// Character 0: O's bar (uppercase accepted for X for backward compatibility)
 	if boardStr[0] >= 'A' && boardStr[0] <= 'O' {
 		pos.OnBar["X"] = int(boardStr[0] - 'A' + 1)
 	} else if boardStr[0] >= 'a' && boardStr[0] <= 'o' {
@@ -131,61 +301,169 @@ func parseXGIDBoard(pos *Position, boardStr string) {
 		}
 	}
 
-	// Character 25: X's borne off (we don't track this in board array)
+	// Character 25: X's bar
+	if boardStr[25] >= 'A' && boardStr[25] <= 'O' {
+		pos.OnBar["X"] = int(boardStr[25] - 'A' + 1)
+	}
+
+	return nil
+}
+
+// rankMarkerRe matches the rank marker opening an evaluation line ("1." or "1)")
+var rankMarkerRe = regexp.MustCompile(`^\s*\d+[.)]`)
+
+// rankRe captures the printed rank of a trimmed evaluation line
+var rankRe = regexp.MustCompile(`^(\d+)[.)]`)
+
+// bestMarkers are the non-asterisk markers flagging the best move or cube
+// action: checkmarks, "(best)" and bold "**" markup
+var bestMarkers = []string{"**", "✓", "✔", "(best)", "(Best)", "(BEST)"}
+
+// stripBestMarker removes best-move markers from line and reports whether
+// one was found. A "*" right after a point number is a hit ("13/7*") and is
+// kept as part of the move.
+func stripBestMarker(line string) (string, bool) {
+	found := false
+	for _, marker := range bestMarkers {
+		if strings.Contains(line, marker) {
+			found = true
+			line = strings.ReplaceAll(line, marker, "")
+		}
+	}
+	if !strings.Contains(line, "*") {
+		return line, found
+	}
+	var b strings.Builder
+	for i, r := range line {
+		if r == '*' && (i == 0 || line[i-1] < '0' || line[i-1] > '9') {
+			found = true
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), found
+}
+
+// probabilityStartRe matches a trimmed probability line ("0.254  0.000..." or "25.4%  0.0%...")
+var probabilityStartRe = regexp.MustCompile(`^\d+\.\d+%?\s`)
+
+// decimalStartRe matches a trimmed line starting with a decimal number
+var decimalStartRe = regexp.MustCompile(`^\d+\.\d+\s`)
+
+// commaDecimalRe matches a number that can only be written with a decimal
+// comma, not a thousands separator: a leading zero ("0,473", "-0,053"), a
+// percentage ("25,4%") or a fraction other than three digits ("0,47", "1,5")
+var commaDecimalRe = regexp.MustCompile(`(?:^|[^\d,])[+-]?0,\d|\d,\d+%|(?:^|[^\d,/])\d+,(?:\d{1,2}|\d{4,})(?:[^\d/]|$)`)
+
+// decimalCommaRe matches a decimal comma in a file using decimal commas.
+// Commas separating the parts of a move ("19/18,14/12") never match, since
+// the point after them is followed by a slash.
+var decimalCommaRe = regexp.MustCompile(`(\d),(\d+)([^\d/]|$)`)
+
+// numberReader parses the numeric fields of TXT lines, keeping the first
+// malformed value instead of silently reading it as zero
+type numberReader struct {
+	err           *strconv.NumError
+	commaDecimals bool // The file writes decimals with a comma
+}
+
+// normalize rewrites the decimal commas of a line as dots ("0,473" becomes
+// "0.473") so localized exports parse like English ones. The file is taken to
+// use decimal commas from the first line with a number that can only be read
+// that way, so thousands separators in other files are left as is.
+func (r *numberReader) normalize(line string) string {
+	if !strings.Contains(line, ",") {
+		return line
+	}
+	if !r.commaDecimals {
+		r.commaDecimals = commaDecimalRe.MatchString(line)
+	}
+	if !r.commaDecimals {
+		return line
+	}
+	return decimalCommaRe.ReplaceAllString(line, "$1.$2$3")
+}
+
+// float parses a decimal field, returning 0 when it is malformed
+func (r *numberReader) float(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil && r.err == nil {
+		r.err = err.(*strconv.NumError)
+	}
+	return v
 }
 
-// parseEvaluation parses a single evaluation line
-func parseEvaluation(line string, rank *int) *Evaluation {
+// parseEvaluation parses a single evaluation line, reporting whether the
+// line printed the evaluation's diff
+func parseEvaluation(line string, rank *int, nums *numberReader) (*Evaluation, bool) {
+	line, isBest := stripBestMarker(nums.normalize(line))
 	originalLine := line
 	line = strings.TrimSpace(line)
 	if line == "" || strings.HasPrefix(line, "=") {
-		return nil
+		return nil, false
 	}
 
 	// Skip lines that are just probabilities (second line of each evaluation)
 	// These lines start with a decimal number (e.g., "0.254  0.000...")
 	// after trimming, not with a rank marker (e.g., "1." or "1)")
 	// Check the original untrimmed line for the rank marker
-	if !regexp.MustCompile(`^\s*\d+[.)]`).MatchString(originalLine) {
+	if !rankMarkerRe.MatchString(originalLine) {
 		// No rank marker at start of original line, so this is not an evaluation line
-		return nil
+		return nil, false
 	}
 
 	// Also skip if the trimmed line starts with a decimal number
-	// (probability lines like "0.254  0.000  0.000  -  0.746...")
-	if regexp.MustCompile(`^\d+\.\d+\s`).MatchString(line) {
-		return nil
+	// (probability lines like "0.254  0.000  0.000  -  0.746..." or "25.4%  0.0% ...")
+	if probabilityStartRe.MatchString(line) {
+		return nil, false
 	}
 
-	eval := &Evaluation{}
+	eval := &Evaluation{IsBest: isBest}
 
-	// Check if this is marked as best move
-	if strings.Contains(line, "*") {
-		eval.IsBest = true
-		line = strings.ReplaceAll(line, "*", "")
+	// An inline comment may follow the move ("19/18, 14/12  # safest")
+	if before, comment, found := strings.Cut(line, " #"); found {
+		eval.Comment = strings.TrimSpace(comment)
+		line = before
 	}
 
 	// Parse rank number at start - support both formats: "1)" and "1."
 	// Format 1: "1) 13-11 24-23                0.473 / -0.289"
 	// Format 2: "1.   0.124 mwp /  -0.492            19/18, 14/12"
-	re := regexp.MustCompile(`^(\d+)[.)]`)
-	matches := re.FindStringSubmatch(line)
+	// Tied moves share the same printed rank, so Rank is assigned sequentially
+	matches := rankRe.FindStringSubmatch(line)
 	if len(matches) == 2 {
-		rankNum, _ := strconv.Atoi(matches[1])
-		eval.Rank = rankNum
-		*rank = rankNum
+		eval.PrintedRank, _ = strconv.Atoi(matches[1])
 		line = line[len(matches[0]):]
 	} else {
-		return nil
+		return nil, false
 	}
 
 	// Trim whitespace after rank
 	line = strings.TrimSpace(line)
 
+	// Extract the parenthesized diff first so it doesn't end up in the move
+	// Forms: "(-0.053)" and "( -0.053)", anywhere on the line
+	rest, diff, hasDiff := extractEvaluationDiff(line)
+	if hasDiff {
+		eval.Diff = diff
+		line = rest
+	}
+
+	// Rollouts print confidence intervals after the values ("-0.492 ± 0.012")
+	line, eval.EquityCI = extractEquityCI(line)
+
+	// Newer exports print the probabilities on the evaluation line itself
+	if loc := inlineProbabilitiesRe.FindStringSubmatchIndex(line); loc != nil {
+		if parseProbabilityLine(line[loc[2]:loc[3]], eval, nums) {
+			line = strings.TrimSpace(line[:loc[2]] + " " + line[loc[3]:])
+		}
+	}
+
 	// Parse the rest of the line
 	parts := strings.Fields(line)
 	if len(parts) < 2 {
-		return nil
+		return nil, false
 	}
 
 	// Check for "mwp" format (new style)
@@ -200,15 +478,8 @@ func parseEvaluation(line string, rank *int) *Evaluation {
 		for i := 0; i < len(parts); i++ {
 			if parts[i] == "/" && i+1 < len(parts) {
 				// parts[i+1] is the EMG equity value
-				eval.Equity, _ = strconv.ParseFloat(parts[i+1], 64)
+				eval.Equity = nums.float(strings.Trim(parts[i+1], "()"))
 				moveStartIdx = i + 2 // Skip "/" and EMG value
-				// Check if there's a diff in parentheses
-				if moveStartIdx < len(parts) && strings.HasPrefix(parts[moveStartIdx], "(") {
-					// Parse diff
-					diffStr := strings.Trim(parts[moveStartIdx], "()")
-					eval.Diff, _ = strconv.ParseFloat(diffStr, 64)
-					moveStartIdx++
-				}
 				break
 			}
 		}
@@ -236,26 +507,177 @@ func parseEvaluation(line string, rank *int) *Evaluation {
 
 			// Parse EMG equity (after "/") — this is the actual equity value
 			if slashIdx+1 < len(parts) {
-				eval.Equity, _ = strconv.ParseFloat(parts[slashIdx+1], 64)
+				eval.Equity = nums.float(strings.Trim(parts[slashIdx+1], "()"))
 			}
 		}
 	}
 
-	return eval
+	// Only accepted lines take a rank
+	*rank++
+	eval.Rank = *rank
+	return eval, hasDiff
+}
+
+// inlineProbabilitiesRe matches the win and lose probabilities printed on an
+// evaluation line, as decimals or percentages: "0.254 0.000 0.000 - 0.746 0.338 0.004"
+var inlineProbabilitiesRe = regexp.MustCompile(`(?:^|\s)((?:\d+\.\d+%?\s+){3}-\s+(?:\d+\.\d+%?\s+){2}\d+\.\d+%?)(?:\s|$)`)
+
+// equityCIRe matches a rollout confidence interval like "± 0.012" or "+/- 0.012"
+var equityCIRe = regexp.MustCompile(`(?:±|\+/-|\+-|＋/－)\s*(\d+\.\d+)`)
+
+// extractEquityCI removes the confidence intervals from an evaluation line and
+// returns the one of the equity, which follows the " / " separator (the
+// interval before it belongs to the MWP value)
+func extractEquityCI(line string) (string, float64) {
+	locs := equityCIRe.FindAllStringSubmatchIndex(line, -1)
+	if locs == nil {
+		return line, 0
+	}
+
+	slash := strings.Index(line, " / ")
+	ci := 0.0
+	for _, loc := range locs {
+		if loc[0] > slash {
+			ci, _ = strconv.ParseFloat(line[loc[2]:loc[3]], 64)
+		}
+	}
+
+	return equityCIRe.ReplaceAllString(line, ""), ci
+}
+
+// evalDiffRe matches a parenthesized equity difference like "(-0.053)" or "( -0.053)"
+var evalDiffRe = regexp.MustCompile(`\(\s*([+-]?\d+\.\d+)\s*\)`)
+
+// extractEvaluationDiff removes the parenthesized diff from an evaluation line
+// and returns the remaining line. A parenthesized value directly after "/" is
+// the equity itself (e.g. "0.473 / (-0.289)") and is left in place.
+func extractEvaluationDiff(line string) (string, float64, bool) {
+	for _, loc := range evalDiffRe.FindAllStringSubmatchIndex(line, -1) {
+		if strings.HasSuffix(strings.TrimSpace(line[:loc[0]]), "/") {
+			continue
+		}
+		diff, err := strconv.ParseFloat(line[loc[2]:loc[3]], 64)
+		if err != nil {
+			continue
+		}
+		return strings.TrimSpace(line[:loc[0]] + " " + line[loc[1]:]), diff, true
+	}
+	return line, 0, false
+}
+
+// fillEvaluationDiffs derives the diff of evaluations that don't print one
+// (e.g. the "0.473 / -0.289" format) from their equity relative to the best move.
+// printed[i] reports whether evals[i] printed its diff, kept even when 0.
+// The best move always has a zero diff.
+func fillEvaluationDiffs(evals []Evaluation, printed []bool) {
+	if len(evals) == 0 {
+		return
+	}
+
+	best := evals[0].Equity
+	evals[0].Diff = 0
+	for i := 1; i < len(evals); i++ {
+		if !printed[i] {
+			evals[i].Diff = math.Round((evals[i].Equity-best)*1000) / 1000
+		}
+	}
+}
+
+// probabilityProblem describes why the probabilities of an evaluation are
+// implausible, or returns "" when they are not: each must lie in [0,1] and
+// gammons cannot exceed the games won or lost
+func probabilityProblem(e Evaluation) string {
+	values := []struct {
+		name  string
+		value float64
+	}{{"win", e.Win}, {"win_g", e.WinG}, {"win_bg", e.WinBG}, {"lose_g", e.LoseG}, {"lose_bg", e.LoseBG}}
+	for _, v := range values {
+		if v.value < 0 || v.value > 1 {
+			return fmt.Sprintf("%s %v outside [0,1]", v.name, v.value)
+		}
+	}
+	if e.WinG > e.Win {
+		return fmt.Sprintf("win_g %v exceeds win %v", e.WinG, e.Win)
+	}
+	if lose := 1 - e.Win; e.LoseG > lose+1e-9 {
+		return fmt.Sprintf("lose_g %v exceeds lose %v", e.LoseG, math.Round(lose*1000)/1000)
+	}
+	return ""
+}
+
+// checkProbabilities warns about evaluations with implausible probabilities,
+// typically read from a mis-split line, and in strict mode clears them
+func checkProbabilities(pos *Position, strict bool) {
+	for i := range pos.Evaluations {
+		e := &pos.Evaluations[i]
+		problem := probabilityProblem(*e)
+		if problem == "" {
+			continue
+		}
+		warning := fmt.Sprintf("evaluation %d (%s): implausible probabilities, %s", e.Rank, e.Move, problem)
+		if strict {
+			e.Win, e.WinG, e.WinBG, e.LoseG, e.LoseBG = 0, 0, 0, 0, 0
+			warning += ", cleared"
+		}
+		pos.Warnings = append(pos.Warnings, warning)
+	}
+}
+
+// markBestEvaluation flags the first-ranked evaluation as best when the file
+// marked none
+func markBestEvaluation(evals []Evaluation) {
+	for _, e := range evals {
+		if e.IsBest {
+			return
+		}
+	}
+	if len(evals) > 0 {
+		evals[0].IsBest = true
+	}
+}
+
+// moveTokenRe matches a single checker move token such as "13/11", "bar/24,"
+// or "6/4*(2)", in slash or dash notation ("13-11"), as found on wrapped
+// move continuation lines
+var moveTokenRe = regexp.MustCompile(`(?i)^(?:bar|\d+)[/-](?:off|\d+)\*?(?:\(\d+\))?,?$`)
+
+// parseMoveContinuation recognizes the continuation of a move list that
+// wrapped past the evaluation line: an indented line made only of move tokens
+func parseMoveContinuation(line string) (string, bool) {
+	if line == "" || (line[0] != ' ' && line[0] != '\t') {
+		return "", false
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", false
+	}
+	for _, field := range fields {
+		if !moveTokenRe.MatchString(field) {
+			return "", false
+		}
+	}
+	return strings.Join(fields, " "), true
 }
 
 // parseProbabilityLine parses the probability detail line that follows an evaluation
 // Format: "   0.443  0.113  0.002  -  0.557  0.179  0.003"
 // Which represents: Win WinG WinBG - (Lose implied) LoseG LoseBG
-func parseProbabilityLine(line string, eval *Evaluation) bool {
-	line = strings.TrimSpace(line)
+func parseProbabilityLine(line string, eval *Evaluation, nums *numberReader) bool {
+	line = strings.TrimSpace(nums.normalize(line))
 	if line == "" {
 		return false
 	}
 
+	// Rollout confidence intervals on probabilities are not kept
+	line = strings.TrimSpace(equityCIRe.ReplaceAllString(line, ""))
+
+	if strings.Contains(line, "%") {
+		return parsePercentProbabilityLine(line, eval)
+	}
+
 	// Check if this looks like a probability line
 	// Should start with a decimal number and contain a dash separator
-	if !regexp.MustCompile(`^\d+\.\d+\s`).MatchString(line) {
+	if !decimalStartRe.MatchString(line) {
 		return false
 	}
 
@@ -282,14 +704,45 @@ func parseProbabilityLine(line string, eval *Evaluation) bool {
 	}
 
 	// Parse win probabilities (before dash)
-	eval.Win, _ = strconv.ParseFloat(parts[0], 64)
-	eval.WinG, _ = strconv.ParseFloat(parts[1], 64)
-	eval.WinBG, _ = strconv.ParseFloat(parts[2], 64)
+	eval.Win = nums.float(parts[0])
+	eval.WinG = nums.float(parts[1])
+	eval.WinBG = nums.float(parts[2])
 
 	// Parse lose probabilities (after dash)
 	// Note: parts[dashIdx+1] is the lose probability (1 - win), we skip it
-	eval.LoseG, _ = strconv.ParseFloat(parts[dashIdx+2], 64)
-	eval.LoseBG, _ = strconv.ParseFloat(parts[dashIdx+3], 64)
+	eval.LoseG = nums.float(parts[dashIdx+2])
+	eval.LoseBG = nums.float(parts[dashIdx+3])
+
+	return true
+}
+
+// percentRe matches a percentage value such as "44.3%"
+var percentRe = regexp.MustCompile(`^(\d+(?:\.\d+)?)%$`)
+
+// parsePercentProbabilityLine parses probabilities printed as percentages,
+// with or without the dash separator, storing them as fractions like parseProbabilityLine
+// Format: "25.4% 0.0% 0.0% - 74.6% 33.8% 0.4%"
+func parsePercentProbabilityLine(line string, eval *Evaluation) bool {
+	var values []float64
+	for _, part := range strings.Fields(line) {
+		if part == "-" {
+			continue
+		}
+		matches := percentRe.FindStringSubmatch(part)
+		if matches == nil {
+			return false
+		}
+		v, _ := strconv.ParseFloat(matches[1], 64)
+		values = append(values, v/100)
+	}
+
+	if len(values) != 6 {
+		return false
+	}
+
+	// values[3] is the lose probability (1 - win), we skip it
+	eval.Win, eval.WinG, eval.WinBG = values[0], values[1], values[2]
+	eval.LoseG, eval.LoseBG = values[4], values[5]
 
 	return true
 }
@@ -299,8 +752,8 @@ func parseProbabilityLine(line string, eval *Evaluation) bool {
 //
 //	"Equity Red (cubeless): 0.139  Std.Dev.: 0.132"
 //	"Equity (cubeful)    :  0.226"
-func parseEquityInfo(line string, pos *Position) {
-	line = strings.TrimSpace(line)
+func parseEquityInfo(line string, pos *Position, nums *numberReader) {
+	line = strings.TrimSpace(nums.normalize(line))
 
 	// Parse cubeless equity and standard deviation
 	// English: "Equity ... (cubeless): X.XXX  Std.Dev.: X.XXX"
@@ -309,10 +762,9 @@ func parseEquityInfo(line string, pos *Position) {
 	if strings.Contains(strings.ToLower(line), "cubeless") ||
 		strings.Contains(line, "ohne Doppler") ||
 		strings.Contains(line, "キューブなし") {
-		re := regexp.MustCompile(`([+-]?\d+\.\d+)`)
-		matches := re.FindAllString(line, -1)
+		matches := cubeNumberRe.FindAllString(line, -1)
 		if len(matches) >= 1 {
-			pos.CubelessEquity, _ = strconv.ParseFloat(matches[0], 64)
+			pos.CubelessEquity = nums.float(matches[0])
 		}
 
 		// Parse standard deviation
@@ -321,7 +773,7 @@ func parseEquityInfo(line string, pos *Position) {
 			strings.Contains(line, "Std.Abw.") ||
 			strings.Contains(line, "標準偏差") {
 			if len(matches) >= 2 {
-				pos.EquityStdDev, _ = strconv.ParseFloat(matches[1], 64)
+				pos.EquityStdDev = nums.float(matches[1])
 			}
 		}
 	}
@@ -335,17 +787,23 @@ func parseEquityInfo(line string, pos *Position) {
 		strings.Contains(line, "mit Doppler") ||
 		strings.Contains(line, "avec videau") ||
 		strings.Contains(line, "キューブ有り") {
-		re := regexp.MustCompile(`([+-]?\d+\.\d+)`)
-		matches := re.FindAllString(line, -1)
+		matches := cubeNumberRe.FindAllString(line, -1)
 		if len(matches) >= 1 {
-			pos.CubefulEquity, _ = strconv.ParseFloat(matches[0], 64)
+			pos.CubefulEquity = nums.float(matches[0])
 		}
 	}
 }
 
+// cubeNumberRe matches a signed decimal value on a cube decision line
+var cubeNumberRe = regexp.MustCompile(`[+-]?\d+\.\d+`)
+
+// cubeDiffRe matches a parenthesized cube decision difference in any of the
+// spacings BGBlitz prints: "(-0.003)", "( 0.000)", "( +0.000)", "(- 0.003)", "(+0.0000)"
+var cubeDiffRe = regexp.MustCompile(`\(\s*([+-]?)\s*(\d+\.\d+)\s*\)`)
+
 // parseCubeDecision parses a cube decision line
-func parseCubeDecision(line string) *CubeDecision {
-	line = strings.TrimSpace(line)
+func parseCubeDecision(line string, nums *numberReader) *CubeDecision {
+	line = strings.TrimSpace(nums.normalize(line))
 
 	// Must contain a colon and decimal numbers to be a cube decision line
 	if !strings.Contains(line, ":") {
@@ -353,17 +811,12 @@ func parseCubeDecision(line string) *CubeDecision {
 	}
 
 	// Must have at least one decimal number
-	re := regexp.MustCompile(`\d+\.\d+`)
-	if !re.MatchString(line) {
+	if !cubeNumberRe.MatchString(line) {
 		return nil
 	}
 
 	decision := &CubeDecision{}
-
-	if strings.Contains(line, "*") {
-		decision.IsBest = true
-		line = strings.ReplaceAll(line, "*", "")
-	}
+	line, decision.IsBest = stripBestMarker(line)
 
 	// Extract action name (everything before the first colon)
 	parts := strings.SplitN(line, ":", 2)
@@ -375,31 +828,28 @@ func parseCubeDecision(line string) *CubeDecision {
 	// Example: " No Double : 0.226 ( 0.000) 0.287 ( 0.000)"
 
 	// Parse differences in parentheses first (MWC diff, EMG diff)
-	reDiff := regexp.MustCompile(`\(([+-]?\s*\d+\.\d+)\)`)
-	diffMatches := reDiff.FindAllStringSubmatch(line, -1)
+	diffMatches := cubeDiffRe.FindAllStringSubmatch(line, -1)
 
 	// Remove parenthesized values to find non-parenthesized decimals
-	lineWithoutParens := reDiff.ReplaceAllString(line, "")
+	lineWithoutParens := cubeDiffRe.ReplaceAllString(line, "")
 
 	// Now find decimal numbers that are NOT in parentheses
-	matches := re.FindAllString(lineWithoutParens, -1)
+	matches := cubeNumberRe.FindAllString(lineWithoutParens, -1)
 
 	// matches[0] = MWC, matches[1] = EMG
 	if len(matches) >= 1 {
-		decision.MWC, _ = strconv.ParseFloat(matches[0], 64)
+		decision.MWC = nums.float(matches[0])
 	}
 	if len(matches) >= 2 {
-		decision.EMG, _ = strconv.ParseFloat(matches[1], 64)
+		decision.EMG = nums.float(matches[1])
 	}
 
+	// The sign may be separated from the digits, e.g. "(- 0.053)"
 	if len(diffMatches) >= 1 {
-		// Remove any spaces in the captured group
-		diffStr := strings.TrimSpace(diffMatches[0][1])
-		decision.MWCDiff, _ = strconv.ParseFloat(diffStr, 64)
+		decision.MWCDiff = nums.float(diffMatches[0][1] + diffMatches[0][2])
 	}
 	if len(diffMatches) >= 2 {
-		diffStr := strings.TrimSpace(diffMatches[1][1])
-		decision.EMGDiff, _ = strconv.ParseFloat(diffStr, 64)
+		decision.EMGDiff = nums.float(diffMatches[1][1] + diffMatches[1][2])
 	}
 
 	return decision
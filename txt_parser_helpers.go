@@ -2,17 +2,29 @@ package bgfparser
 
 import (
 	"bufio"
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 )
 
+// boardRowRe matches a row of the ASCII board: both halves and the bar
+// between the borders, as laid out by ToBoardASCII
+var boardRowRe = regexp.MustCompile(`^.?\|.{18}\|.{3}\|.{18}\|`)
+
 // parseBoardLine checks if a line is part of the board display
 func parseBoardLine(line string, boardLines *[]string) bool {
 	if !strings.Contains(line, "|") {
 		return false
 	}
 
+	// Rows of the board drawing, which may carry the cube box or only the
+	// count of a tall stack
+	if boardRowRe.MatchString(line) {
+		*boardLines = append(*boardLines, line)
+		return true
+	}
+
 	if strings.Contains(line, "+") {
 		// Board boundary lines - skip
 		return true
@@ -26,70 +38,203 @@ func parseBoardLine(line string, boardLines *[]string) bool {
 	return false
 }
 
-// parsePlayerInfo extracts player names and pip counts
+// playerRatingRe matches an optional rating and experience after a player name,
+// e.g. "X: Red (1712.4/850)" or "O: Jean Pierre (1650)"
+var playerRatingRe = regexp.MustCompile(`\b([OX]):\s*([^\s():][^():]*?)\s*\(\s*(\d+(?:\.\d+)?)\s*(?:[/,]\s*(\d+)\s*)?\)`)
+
+// heroMarkerRe matches a player name marked as the human player, e.g.
+// "O: Green (you)"; English, French, German, Japanese
+var heroMarkerRe = regexp.MustCompile(`\b([OX]):\s*([^():]*?)\s*\(\s*(?i:you|vous|toi|du|sie|あなた)\s*\)`)
+
+// parsePlayerInfo extracts player names, ratings and pip counts
 func parsePlayerInfo(line string, pos *Position) {
 	// Look for either "O:" or "X:" in the line
 	if !strings.Contains(line, "O:") && !strings.Contains(line, "X:") {
 		return
 	}
 
+	if m := heroMarkerRe.FindStringSubmatch(line); m != nil {
+		pos.Hero = m[1]
+		line = heroMarkerRe.ReplaceAllString(line, "$1: $2")
+	}
+
+	for _, matches := range playerRatingRe.FindAllStringSubmatch(line, -1) {
+		rating, _ := strconv.ParseFloat(matches[3], 64)
+		experience, _ := strconv.Atoi(matches[4])
+		if matches[1] == "X" {
+			pos.RatingX, pos.ExperienceX = rating, experience
+		} else {
+			pos.RatingO, pos.ExperienceO = rating, experience
+		}
+	}
+	// Drop the ratings so the pip count follows the name
+	line = playerRatingRe.ReplaceAllString(line, "$1: $2")
+
 	parts := strings.Fields(line)
 	for i, part := range parts {
-		if part == "O:" && i+1 < len(parts) {
-			pos.PlayerO = parts[i+1]
-			if i+2 < len(parts) {
-				if score, err := strconv.Atoi(parts[i+2]); err == nil {
-					pos.PipCount["O"] = score
-				}
-			}
+		if part != "O:" && part != "X:" {
+			continue
 		}
-		if part == "X:" && i+1 < len(parts) {
-			pos.PlayerX = parts[i+1]
-			if i+2 < len(parts) {
-				if score, err := strconv.Atoi(parts[i+2]); err == nil {
-					pos.PipCount["X"] = score
-				}
+		player := part[:1]
+
+		// The name runs up to the pip count, which ends the player's fields
+		fields := parts[i+1:]
+		for j, f := range fields {
+			if f == "O:" || f == "X:" {
+				fields = fields[:j]
+				break
 			}
 		}
+		if len(fields) == 0 {
+			continue
+		}
+		if last := fields[len(fields)-1]; len(fields) > 1 && strings.ContainsAny(last, "0123456789") {
+			parsePipCount(last, player, pos)
+			fields = fields[:len(fields)-1]
+		}
+
+		name := strings.Trim(strings.Join(fields, " "), `"`)
+		if player == "X" {
+			pos.PlayerX = name
+		} else {
+			pos.PlayerO = name
+		}
 	}
 }
 
+// parsePipCount stores the pip count following a player name. Values that
+// look numeric but are malformed or negative are ignored with a warning.
+func parsePipCount(field, player string, pos *Position) {
+	pips, err := strconv.Atoi(field)
+	switch {
+	case err != nil:
+		if strings.ContainsAny(field, "0123456789") {
+			pos.Warnings = append(pos.Warnings, fmt.Sprintf("ignored invalid pip count %q for player %s", field, player))
+		}
+	case pips < 0:
+		pos.Warnings = append(pos.Warnings, fmt.Sprintf("ignored negative pip count %d for player %s", pips, player))
+	default:
+		pos.PipCount[player] = pips
+	}
+}
+
+// metadataLabels maps localized header labels to the metadata field they fill
+// English, French, German, Japanese
+var metadataLabels = map[string]string{
+	"Date": "date", "Datum": "date", "日付": "date",
+	"Event": "event", "Événement": "event", "Evenement": "event", "Turnier": "event", "Ereignis": "event", "イベント": "event",
+	"Site": "site", "Lieu": "site", "Ort": "site", "場所": "site",
+	"Round": "round", "Ronde": "round", "Manche": "round", "Runde": "round", "ラウンド": "round",
+	"Engine": "engine", "Moteur": "engine", "Programm": "engine", "エンジン": "engine",
+	"Settings": "settings", "Paramètres": "settings", "Parametres": "settings", "Einstellungen": "settings", "設定": "settings",
+}
+
+// engineFooterRe matches an analysis footer naming the engine and, in
+// parentheses, its settings, e.g. "Analyzed with BGBlitz 6.7.0 (3-ply)"
+var engineFooterRe = regexp.MustCompile(`^(?:Analyzed with|Evaluated by|Analysé avec|Analysiert mit)\s+([^(]+?)\s*(?:\((.*)\))?$`)
+
+// parseMetadata extracts Date, Event, Site, Round, Engine and Settings lines
+// Format: "Date: 2025-11-04" (the Japanese full-width colon is also accepted)
+// or an engine footer such as "Analyzed with BGBlitz 6.7.0 (3-ply)"
+func parseMetadata(line string, pos *Position) bool {
+	line = strings.TrimSpace(strings.Replace(line, "：", ":", 1))
+	if matches := engineFooterRe.FindStringSubmatch(line); matches != nil {
+		pos.Engine = matches[1]
+		if matches[2] != "" {
+			pos.Settings = matches[2]
+		}
+		return true
+	}
+
+	label, value, found := strings.Cut(line, ":")
+	if !found {
+		return false
+	}
+
+	field, ok := metadataLabels[strings.TrimSpace(label)]
+	if !ok {
+		return false
+	}
+
+	value = strings.TrimSpace(value)
+	switch field {
+	case "date":
+		pos.Date = value
+	case "event":
+		pos.Event = value
+	case "site":
+		pos.Site = value
+	case "round":
+		pos.Round = value
+	case "engine":
+		pos.Engine = value
+	case "settings":
+		pos.Settings = value
+	}
+	return true
+}
+
+// commentRe matches a comment line, prefixed with "#" or a localized
+// "Comment:" label, capturing its text
+var commentRe = regexp.MustCompile(`^\s*(?:#|(?i:comment|commentaire|kommentar)\s*:|コメント\s*[:：])\s?(.*?)\s*$`)
+
+// parseCommentLine appends the text of a comment line to pos.Comment, each
+// comment line on its own line
+func parseCommentLine(line string, pos *Position) bool {
+	matches := commentRe.FindStringSubmatch(line)
+	if matches == nil {
+		return false
+	}
+	if pos.Comment != "" {
+		pos.Comment += "\n"
+	}
+	pos.Comment += matches[1]
+	return true
+}
+
+// positionIDRe captures the Position-ID and Match-ID of a position
+var positionIDRe = regexp.MustCompile(`Position-ID:\s*(\S+)\s+Match-ID:\s*(\S+)`)
+
 // parsePositionID extracts Position-ID and Match-ID
 func parsePositionID(line string, pos *Position) {
 	if !strings.Contains(line, "Position-ID:") {
 		return
 	}
 
-	re := regexp.MustCompile(`Position-ID:\s*(\S+)\s+Match-ID:\s*(\S+)`)
-	matches := re.FindStringSubmatch(line)
+	matches := positionIDRe.FindStringSubmatch(line)
 	if len(matches) == 3 {
 		pos.PositionID = matches[1]
 		pos.MatchID = matches[2]
 	}
 }
 
+// xgidRe captures the XGID of a position
+var xgidRe = regexp.MustCompile(`XGID=(\S+)`)
+
 // parseXGIDLine extracts and parses XGID
-func parseXGIDLine(line string, pos *Position) {
+func parseXGIDLine(line string, pos *Position) error {
 	if !strings.Contains(line, "XGID=") {
-		return
+		return nil
 	}
 
-	re := regexp.MustCompile(`XGID=(\S+)`)
-	matches := re.FindStringSubmatch(line)
+	matches := xgidRe.FindStringSubmatch(line)
 	if len(matches) == 2 {
 		pos.XGID = matches[1]
-		parseXGID(pos, matches[1])
+		return parseXGID(pos, matches[1])
 	}
+	return nil
 }
 
+// matchScoreRe captures the scores and match length, e.g. "Green - 6 Red - 3 in a 7 point match"
+var matchScoreRe = regexp.MustCompile(`(\S+)\s*-\s*(\d+)\s+(\S+)\s*-\s*(\d+)\s+in a\s+(\d+)\s+point match`)
+
 // parseMatchScore extracts match length and scores
 func parseMatchScore(line string, pos *Position) {
 	if !strings.Contains(line, "point match") {
 		return
 	}
 
-	re := regexp.MustCompile(`(\S+)\s*-\s*(\d+)\s+(\S+)\s*-\s*(\d+)\s+in a\s+(\d+)\s+point match`)
-	matches := re.FindStringSubmatch(line)
+	matches := matchScoreRe.FindStringSubmatch(line)
 	if len(matches) == 6 {
 		pos.ScoreO, _ = strconv.Atoi(matches[2])
 		pos.ScoreX, _ = strconv.Atoi(matches[4])
@@ -97,6 +242,10 @@ func parseMatchScore(line string, pos *Position) {
 	}
 }
 
+// diceRe captures the dice following "to move", e.g. "1-2", "3,2" or "rolls 3 2".
+// It is anchored there so that a score such as "(5-3)" earlier on the line is not taken.
+var diceRe = regexp.MustCompile(`^\s*(?:rolls\s+)?([1-6])\s*[-,\s]\s*([1-6])\b`)
+
 // parseCurrentPlayer extracts current player and dice
 func parseCurrentPlayer(line string, pos *Position) {
 	if !strings.Contains(line, "to move") {
@@ -110,14 +259,17 @@ func parseCurrentPlayer(line string, pos *Position) {
 	}
 
 	// Parse dice
-	re := regexp.MustCompile(`(\d+)-(\d+)`)
-	matches := re.FindStringSubmatch(line)
+	_, rest, _ := strings.Cut(line, "to move")
+	matches := diceRe.FindStringSubmatch(rest)
 	if len(matches) == 3 {
 		pos.Dice[0], _ = strconv.Atoi(matches[1])
 		pos.Dice[1], _ = strconv.Atoi(matches[2])
 	}
 }
 
+// cubeValueRe captures the value shown in the cube box, e.g. "| 2|"
+var cubeValueRe = regexp.MustCompile(`\|\s*(\d+)\s*\|`)
+
 // parseCubeValue extracts cube value from display
 func parseCubeValue(line string, scanner *bufio.Scanner, pos *Position) bool {
 	if !strings.Contains(line, "+--+") {
@@ -133,8 +285,7 @@ func parseCubeValue(line string, scanner *bufio.Scanner, pos *Position) bool {
 		return true
 	}
 
-	re := regexp.MustCompile(`\|\s*(\d+)\s*\|`)
-	matches := re.FindStringSubmatch(cubeLine)
+	matches := cubeValueRe.FindStringSubmatch(cubeLine)
 	if len(matches) == 2 {
 		pos.CubeValue, _ = strconv.Atoi(matches[1])
 	}
@@ -175,3 +326,331 @@ func handleEvaluationSection(line string, inEvaluation, inCubeDecision *bool, ev
 
 	return false
 }
+
+// rollTableLabels are the localized headers of the per-roll equity table
+var rollTableLabels = []string{"Roll equities", "Équités par lancer", "Equity pro Wurf", "出目別エクイティ"}
+
+// rollEquityRe matches a roll and its equity in the per-roll table ("6-5: 0.512")
+var rollEquityRe = regexp.MustCompile(`\b([1-6])-([1-6])\s*[:：]?\s*([+-]?\d+\.\d+)`)
+
+// parseRollEquityLine handles the per-roll equity table: its header starts
+// the table, whose entries are stored in pos.RollEquities until a line
+// without any. It reports whether the line belonged to the table.
+func parseRollEquityLine(line string, inTable *bool, pos *Position, nums *numberReader) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, label := range rollTableLabels {
+		if strings.HasPrefix(trimmed, label) {
+			*inTable = true
+			pos.RollEquities = make(map[string]float64)
+			return true
+		}
+	}
+	if !*inTable {
+		return false
+	}
+
+	matches := rollEquityRe.FindAllStringSubmatch(nums.normalize(line), -1)
+	if matches == nil {
+		// Blank and underline lines may separate the header from the entries
+		if len(pos.RollEquities) == 0 && strings.Trim(trimmed, "=-") == "" {
+			return true
+		}
+		*inTable = false
+		return false
+	}
+
+	for _, m := range matches {
+		high, low := m[1], m[2]
+		if high < low {
+			high, low = low, high
+		}
+		pos.RollEquities[high+"-"+low], _ = strconv.ParseFloat(m[3], 64)
+	}
+	return true
+}
+
+// cubeLifeLineRe matches a localized dead/live cube equity line, e.g.
+// "Cube: dead 0.344  live 0.452", capturing the text after the label
+var cubeLifeLineRe = regexp.MustCompile(`^\s*(?:Cube|Videau|Würfel|キューブ)\s*[:：]\s*(.*)$`)
+
+// cubeLifeValueRe captures a dead or live cube word and its equity
+var cubeLifeValueRe = regexp.MustCompile(`(?i)(dead|mort|tot|デッド|live|vivant|lebendig|ライブ)\s*[:：]?\s*([+-]?\d+\.\d+)`)
+
+// deadCubeWords are the localized words marking the dead cube equity
+var deadCubeWords = map[string]bool{"dead": true, "mort": true, "tot": true, "デッド": true}
+
+// recubeVigRe captures the localized recube vig value, e.g. "Recube vig: 0.042"
+var recubeVigRe = regexp.MustCompile(`(?i)^\s*(?:Recube vig|Vig de redoublement|Recube-Vig|リキューブ・ヴィグ)\s*[:：]\s*([+-]?\d+\.\d+)`)
+
+// parseCubeLifeLine parses the dead/live cube equity and recube vig lines of
+// advanced cube analysis
+func parseCubeLifeLine(line string, pos *Position, nums *numberReader) bool {
+	line = nums.normalize(line)
+
+	if matches := recubeVigRe.FindStringSubmatch(line); matches != nil {
+		pos.RecubeVig, _ = strconv.ParseFloat(matches[1], 64)
+		return true
+	}
+
+	matches := cubeLifeLineRe.FindStringSubmatch(line)
+	if matches == nil {
+		return false
+	}
+	values := cubeLifeValueRe.FindAllStringSubmatch(matches[1], -1)
+	if values == nil {
+		return false
+	}
+	for _, v := range values {
+		equity, _ := strconv.ParseFloat(v[2], 64)
+		if deadCubeWords[strings.ToLower(v[1])] {
+			pos.DeadCubeEquity = equity
+		} else {
+			pos.LiveCubeEquity = equity
+		}
+	}
+	return true
+}
+
+// forcedPassWords are the localized markers printed instead of an evaluation
+// list when the player on roll has no legal move
+var forcedPassWords = []string{
+	"cannot move", "can't move", "no legal move", "no valid move", "no possible move",
+	"ne peut pas jouer", "aucun coup",
+	"kann nicht ziehen", "keine züge", "kein zug",
+	"動けません", "動かせません",
+}
+
+// isForcedPassLine reports whether a line states that the player on roll
+// cannot move: a marker, optionally preceded by the player's name and
+// followed by a full stop. Lines merely containing a marker don't count.
+func isForcedPassLine(line string) bool {
+	line = strings.TrimRight(strings.ToLower(strings.TrimSpace(line)), ".!。")
+	for _, w := range forcedPassWords {
+		name, ok := strings.CutSuffix(line, w)
+		if !ok {
+			continue
+		}
+		// The player name is at most a few words, without move numbers
+		return len(strings.Fields(name)) <= 3 && !strings.ContainsAny(name, "0123456789/()#:=")
+	}
+	return false
+}
+
+// analysisKind names the analysis sections present in a file
+func analysisKind(checker, cube bool) string {
+	switch {
+	case checker && cube:
+		return "both"
+	case checker:
+		return "checker"
+	case cube:
+		return "cube"
+	}
+	return ""
+}
+
+// cubeRecommendationRe matches the recommended cube action, either on the
+// cube section header ("Cube Action: : Double / Take EMG") or on a separate
+// line ("Proper cube action: Double, take")
+var cubeRecommendationRe = regexp.MustCompile(
+	`^\s*(?:(?:Cube Action|Würfelaktion|Videau|キューブアクション)\s*:\s*:\s*(.+?)\s+(?:EMG|MWC)` +
+		`|(?:Proper cube action|Action correcte du videau|Richtige Würfelaktion|正しいキューブアクション)\s*[:：]\s*(.+?))\s*$`)
+
+// parseCubeRecommendation extracts the recommended cube action into pos.Recommendation.
+// An explicit recommendation line takes precedence over the section header.
+func parseCubeRecommendation(line string, pos *Position) {
+	matches := cubeRecommendationRe.FindStringSubmatch(line)
+	if matches == nil {
+		return
+	}
+	if matches[2] != "" {
+		pos.Recommendation = matches[2]
+	} else if pos.Recommendation == "" {
+		pos.Recommendation = matches[1]
+	}
+}
+
+// Localized keywords identifying cube actions (English, French, German, Japanese)
+var (
+	tooGoodWords  = []string{"too good", "trop bon", "zu gut", "トゥーグッド"}
+	noDoubleWords = []string{"no double", "no redouble", "pas de double", "pas de redouble", "kein doppel", "kein redoppel", "ダブルせず", "リダブルせず"}
+	passWords     = []string{"pass", "reject", "drop", "refuser", "rejeter", "ablehnen", "降りる"}
+	takeWords     = []string{"take", "prendre", "annehmen", "受ける"}
+)
+
+// cubeActionKind classifies a localized cube action as "too_good", "no_double",
+// "double_pass" or "double_take" ("" if unknown)
+func cubeActionKind(action string) string {
+	action = strings.ToLower(action)
+	containsAny := func(words []string) bool {
+		for _, w := range words {
+			if strings.Contains(action, w) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case containsAny(tooGoodWords):
+		return "too_good"
+	case containsAny(noDoubleWords):
+		return "no_double"
+	case containsAny(passWords):
+		return "double_pass"
+	case containsAny(takeWords):
+		return "double_take"
+	}
+	return ""
+}
+
+// markRecommendedCubeAction flags the cube decision matching pos.Recommendation
+// as best, unless the file already marked a best decision
+func markRecommendedCubeAction(pos *Position) {
+	kind := cubeActionKind(pos.Recommendation)
+	if kind == "" {
+		return
+	}
+	for _, d := range pos.CubeDecisions {
+		if d.IsBest {
+			return
+		}
+	}
+	for i := range pos.CubeDecisions {
+		if cubeActionKind(pos.CubeDecisions[i].Action) == kind {
+			pos.CubeDecisions[i].IsBest = true
+		}
+	}
+}
+
+// barOffLabels maps localized bar and borne-off labels (English, French, German, Japanese)
+var barOffLabels = map[string]string{
+	"Bar": "bar", "Barre": "bar", "バー": "bar",
+	"Off": "off", "Sortis": "off", "Draußen": "off", "Abgetragen": "off", "上がり": "off",
+}
+
+// barOffRe matches explicit bar and borne-off lines, e.g. "Bar: X=1 O=2"
+var barOffRe = regexp.MustCompile(`^\s*(\S+)\s*[:：]\s*X\s*=\s*(\d+)\s+O\s*=\s*(\d+)\s*$`)
+
+// parseBarOffLine extracts "Bar: X=1 O=2" and "Off: X=3 O=5" lines into
+// OnBar and Off. It returns which label was found ("bar" or "off").
+func parseBarOffLine(line string, pos *Position) (string, bool) {
+	matches := barOffRe.FindStringSubmatch(line)
+	if matches == nil {
+		return "", false
+	}
+
+	label, ok := barOffLabels[matches[1]]
+	if !ok {
+		return "", false
+	}
+
+	x, _ := strconv.Atoi(matches[2])
+	o, _ := strconv.Atoi(matches[3])
+	counts := pos.OnBar
+	if label == "off" {
+		counts = pos.Off
+	}
+	counts["X"] = x
+	counts["O"] = o
+	return label, true
+}
+
+// pipLabels are the localized labels of a dedicated pip count line
+// (English, French, German, Japanese)
+var pipLabels = map[string]bool{
+	"Pip": true, "Pips": true, "Pip count": true, "Pipcount": true,
+	"Compte de pips": true, "Augenzahl": true, "Pipzahl": true,
+	"ピップ": true, "ピップカウント": true,
+}
+
+// pipLineRe matches a dedicated pip count line, e.g. "Pip: X 167 O 160" or "Pips: X=167 O=160"
+var pipLineRe = regexp.MustCompile(`^\s*([^:：]+?)\s*[:：]\s*([XO])\s*=?\s*(\d+)\s+([XO])\s*=?\s*(\d+)\s*$`)
+
+// parsePipLine extracts the pip counts of a dedicated pip count line into pips
+func parsePipLine(line string, pips map[string]int) bool {
+	matches := pipLineRe.FindStringSubmatch(line)
+	if matches == nil || !pipLabels[matches[1]] || matches[2] == matches[4] {
+		return false
+	}
+
+	pips[matches[2]], _ = strconv.Atoi(matches[3])
+	pips[matches[4]], _ = strconv.Atoi(matches[5])
+	return true
+}
+
+// mergePipLine fills the pip counts missing from the player header with those
+// of a dedicated pip count line. The header wins when both give a count,
+// with a warning if they disagree.
+func mergePipLine(pos *Position, pips map[string]int) {
+	for _, player := range []string{"X", "O"} {
+		n, ok := pips[player]
+		if !ok {
+			continue
+		}
+		header, found := pos.PipCount[player]
+		switch {
+		case !found:
+			pos.PipCount[player] = n
+		case header != n:
+			pos.Warnings = append(pos.Warnings, fmt.Sprintf("pip count line gives %d for player %s, keeping %d from the header", n, player, header))
+		}
+	}
+}
+
+// rulesHeaders are the localized headers of the match rules footer block
+// (English, French, German, Japanese)
+var rulesHeaders = map[string]bool{
+	"Rules": true, "Règles": true, "Regles": true, "Regeln": true, "ルール": true,
+}
+
+// ruleLabels maps localized match rule labels to the rule they set
+var ruleLabels = map[string]string{
+	"Crawford": "crawford", "クロフォード": "crawford",
+	"Jacoby": "jacoby", "ジャコビー": "jacoby",
+	"Beavers": "beavers", "Beaver": "beavers", "Castors": "beavers", "Castor": "beavers", "Biber": "beavers", "ビーバー": "beavers",
+	"Raccoons": "raccoons", "Raccoon": "raccoons", "Ratons laveurs": "raccoons", "Raton laveur": "raccoons", "Waschbären": "raccoons", "Waschbär": "raccoons", "ラクーン": "raccoons",
+	"Automatic doubles": "auto_double", "Doublements automatiques": "auto_double", "Automatische Doppel": "auto_double", "自動ダブル": "auto_double",
+}
+
+// ruleEnabledWords are the localized values marking a rule as in use
+var ruleEnabledWords = map[string]bool{
+	"yes": true, "on": true, "true": true, "oui": true, "ja": true, "an": true, "はい": true, "有り": true,
+}
+
+// parseRulesLine parses the match rules footer block: a "Rules:" header
+// followed by one "Label: yes/no" line per rule. inRules tracks whether the
+// header was seen. Rules that are not listed are left false.
+func parseRulesLine(line string, inRules *bool, pos *Position) bool {
+	line = strings.TrimSpace(strings.Replace(line, "：", ":", 1))
+	label, value, found := strings.Cut(line, ":")
+	label, value = strings.TrimSpace(label), strings.TrimSpace(value)
+
+	if rulesHeaders[strings.TrimSuffix(line, ":")] || (found && value == "" && rulesHeaders[label]) {
+		*inRules = true
+		return true
+	}
+	if !*inRules || !found {
+		return false
+	}
+
+	rule, ok := ruleLabels[label]
+	if !ok {
+		return false
+	}
+
+	enabled := ruleEnabledWords[strings.ToLower(value)]
+	switch rule {
+	case "crawford":
+		pos.Rules.Crawford = enabled
+	case "jacoby":
+		pos.Rules.Jacoby = enabled
+	case "beavers":
+		pos.Rules.Beavers = enabled
+	case "raccoons":
+		pos.Rules.Raccoons = enabled
+	case "auto_double":
+		pos.Rules.AutoDouble = enabled
+	}
+	return true
+}
@@ -1,7 +1,14 @@
 package bgfparser_test
 
 import (
+	"errors"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/kevung/bgfparser"
 )
@@ -248,3 +255,1238 @@ func TestParseTXT_ProbabilityExtraction(t *testing.T) {
 		t.Errorf("LoseBG = %.3f, want %.3f", eval.LoseBG, expectedLoseBG)
 	}
 }
+
+// TestParseTXT_Metadata tests that localized Date/Event/Site/Round header lines are parsed
+func TestParseTXT_Metadata(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		date  string
+		event string
+		site  string
+		round string
+	}{
+		{"English", "test/fixtures/metadata_EN.txt", "2025-11-04", "Club Championship", "Paris", "3"},
+		{"French", "test/fixtures/metadata_FR.txt", "04/11/2025", "Championnat du club", "Paris", "3"},
+		{"Without metadata", "test/2025-11-04/01_checkerPosition_EN.txt", "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXT(tt.file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+
+			if pos.Date != tt.date {
+				t.Errorf("Date = %q, want %q", pos.Date, tt.date)
+			}
+			if pos.Event != tt.event {
+				t.Errorf("Event = %q, want %q", pos.Event, tt.event)
+			}
+			if pos.Site != tt.site {
+				t.Errorf("Site = %q, want %q", pos.Site, tt.site)
+			}
+			if pos.Round != tt.round {
+				t.Errorf("Round = %q, want %q", pos.Round, tt.round)
+			}
+
+			// The rest of the position must still parse
+			if pos.XGID == "" || len(pos.Evaluations) != 5 {
+				t.Errorf("Position not fully parsed: XGID=%q, %d evaluations", pos.XGID, len(pos.Evaluations))
+			}
+		})
+	}
+}
+
+// TestParseTXT_TiedRanks tests that tied moves keep their printed rank
+// while Rank stays strictly sequential
+func TestParseTXT_TiedRanks(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/tied_ranks_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	wantPrinted := []int{1, 2, 3, 3, 5}
+	if len(pos.Evaluations) != len(wantPrinted) {
+		t.Fatalf("Expected %d evaluations, got %d", len(wantPrinted), len(pos.Evaluations))
+	}
+
+	for i, eval := range pos.Evaluations {
+		if eval.Rank != i+1 {
+			t.Errorf("Evaluation %d: Rank = %d, want %d", i, eval.Rank, i+1)
+		}
+		if eval.PrintedRank != wantPrinted[i] {
+			t.Errorf("Evaluation %d: PrintedRank = %d, want %d", i, eval.PrintedRank, wantPrinted[i])
+		}
+	}
+
+	// A rejected rank line leaves no gap
+	pos, err = bgfparser.ParseTXTFromReader(strings.NewReader("Evaluation\n==========\n" +
+		" 1) 19/18 14/12    0.124 / -0.492\n" +
+		" 2) truncated\n" +
+		" 3) 19/18 3/1      0.111 / -0.545\n"))
+	if err != nil {
+		t.Fatalf("ParseTXTFromReader failed: %v", err)
+	}
+	if len(pos.Evaluations) != 2 || pos.Evaluations[1].Rank != 2 || pos.Evaluations[1].PrintedRank != 3 {
+		t.Errorf("Evaluations = %+v, want ranks 1 and 2", pos.Evaluations)
+	}
+}
+
+// TestParseTXT_CrawfordState tests the Crawford and post-Crawford flags derived from the XGID
+func TestParseTXT_CrawfordState(t *testing.T) {
+	tests := []struct {
+		name         string
+		xgid         string
+		crawford     bool
+		postCrawford bool
+	}{
+		{"Post-Crawford", "-B-CBBB---a---A---ABcbbbd-:1:-1:1:21:3:6:0:7:10", false, true},
+		{"Crawford game", "-B-CBBB---a---A---ABcbbbd-:0:0:1:21:3:6:1:7:10", true, false},
+		{"Before match point", "-B-CBBB---a---A---ABcbbbd-:1:-1:1:21:3:4:0:7:10", false, false},
+		{"Money game", "-B-CBBB---a---A---ABcbbbd-:1:-1:1:21:0:0:1:0:10", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXTFromReader(strings.NewReader("XGID=" + tt.xgid + "\n"))
+			if err != nil {
+				t.Fatalf("ParseTXTFromReader failed: %v", err)
+			}
+			if pos.Crawford != tt.crawford {
+				t.Errorf("Crawford = %v, want %v", pos.Crawford, tt.crawford)
+			}
+			if pos.PostCrawford != tt.postCrawford {
+				t.Errorf("PostCrawford = %v, want %v", pos.PostCrawford, tt.postCrawford)
+			}
+		})
+	}
+
+	pos, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if !pos.PostCrawford {
+		t.Error("Expected 6-3 in a 7 point match without Crawford flag to be post-Crawford")
+	}
+}
+
+// TestParseTXT_EvaluationDiffForms tests diff extraction from parenthesized,
+// spaced-parenthesized and slash-only evaluation lines
+func TestParseTXT_EvaluationDiffForms(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/eval_diff_forms_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	want := []struct {
+		move   string
+		equity float64
+		diff   float64
+	}{
+		{"19/18 14/12", -0.492, 0},
+		{"19/18 3/1", -0.545, -0.053},
+		{"19/17 18/17", -0.577, -0.085},
+		{"14/12 3/2", -0.578, -0.086},
+		{"14/11", -0.585, -0.093},
+	}
+
+	if len(pos.Evaluations) != len(want) {
+		t.Fatalf("Expected %d evaluations, got %d", len(want), len(pos.Evaluations))
+	}
+
+	for i, w := range want {
+		eval := pos.Evaluations[i]
+		if eval.Move != w.move {
+			t.Errorf("Evaluation %d: Move = %q, want %q", i, eval.Move, w.move)
+		}
+		if eval.Equity != w.equity {
+			t.Errorf("Evaluation %d: Equity = %.3f, want %.3f", i, eval.Equity, w.equity)
+		}
+		if eval.Diff != w.diff {
+			t.Errorf("Evaluation %d: Diff = %.3f, want %.3f", i, eval.Diff, w.diff)
+		}
+	}
+
+	// The mwp format keeps its printed diffs
+	pos, err = bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if pos.Evaluations[0].Diff != 0 || pos.Evaluations[1].Diff != -0.053 {
+		t.Errorf("Diffs = %.3f, %.3f, want 0.000, -0.053", pos.Evaluations[0].Diff, pos.Evaluations[1].Diff)
+	}
+
+	// A printed zero diff is kept even when the rounded equities differ
+	pos, err = bgfparser.ParseTXT("test/fixtures/eval_diff_zero_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if len(pos.Evaluations) != 3 {
+		t.Fatalf("Expected 3 evaluations, got %d", len(pos.Evaluations))
+	}
+	if pos.Evaluations[1].Diff != 0 || pos.Evaluations[2].Diff != -0.085 {
+		t.Errorf("Diffs = %.3f, %.3f, want 0.000, -0.085", pos.Evaluations[1].Diff, pos.Evaluations[2].Diff)
+	}
+}
+
+func TestParseTXT_Engine(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		engine   string
+		settings string
+	}{
+		{"Header labels", "test/fixtures/engine_header_EN.txt", "TachiAI 1.2", "2-ply, cubeful"},
+		{"Footer line", "test/fixtures/engine_footer_EN.txt", "BGBlitz 6.7.0", "3-ply, 1296 games rollout"},
+		{"Without engine", "test/2025-11-04/01_checkerPosition_EN.txt", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXT(tt.file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+
+			if pos.Engine != tt.engine {
+				t.Errorf("Engine = %q, want %q", pos.Engine, tt.engine)
+			}
+			if pos.Settings != tt.settings {
+				t.Errorf("Settings = %q, want %q", pos.Settings, tt.settings)
+			}
+
+			jsonData, err := pos.ToJSON()
+			if err != nil {
+				t.Fatalf("ToJSON failed: %v", err)
+			}
+			if got := strings.Contains(string(jsonData), `"engine"`); got != (tt.engine != "") {
+				t.Errorf("JSON engine field present = %v, want %v", got, tt.engine != "")
+			}
+			if len(pos.Evaluations) != 5 {
+				t.Errorf("Expected 5 evaluations, got %d", len(pos.Evaluations))
+			}
+		})
+	}
+}
+
+func TestParseTXT_CubeRecommendation(t *testing.T) {
+	tests := []struct {
+		file           string
+		recommendation string
+		best           string // Action of the decision expected to be flagged best
+	}{
+		{"test/fixtures/cube_recommendation_EN.txt", "Double, pass", "Double / Pass"},
+		{"test/2025-11-04/02_NDT_EN.txt", "No Double / Take", "No Double"},
+		{"test/2025-11-04/03_DT_FR.txt", "Doubler / Prendre", "Double / Prendre"},
+		{"test/2025-11-04/04_DP_DE.txt", "Doppeln / Ablehnen", "Doppeln / Ablehnen"},
+		{"test/2025-11-04/05_NRT_JP.txt", "ダブルせず / 受ける", "ダブルせず"},
+		{"test/2025-11-04/06_RT_EN.txt", "Redouble / Take", "Double / Take"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXT(tt.file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+
+			if pos.Recommendation != tt.recommendation {
+				t.Errorf("Recommendation = %q, want %q", pos.Recommendation, tt.recommendation)
+			}
+
+			for _, d := range pos.CubeDecisions {
+				if want := d.Action == tt.best; d.IsBest != want {
+					t.Errorf("Decision %q IsBest = %v, want %v", d.Action, d.IsBest, want)
+				}
+			}
+		})
+	}
+}
+
+// TestParseTXT_BestMarkers tests the "(best)" and checkmark best-move markers,
+// the rank-1 fallback for unmarked files, and that hit markers in moves
+// ("3/1*") do not flag a move as best
+func TestParseTXT_BestMarkers(t *testing.T) {
+	tests := []struct {
+		file     string
+		cubeBest string // Action of the decision expected to be flagged best
+	}{
+		{"test/fixtures/best_marker_EN.txt", "No Double"},
+		{"test/fixtures/checkmark_EN.txt", ""},
+		{"test/fixtures/tied_ranks_EN.txt", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXT(tt.file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+			if len(pos.Evaluations) != 5 {
+				t.Fatalf("Expected 5 evaluations, got %d", len(pos.Evaluations))
+			}
+
+			for i, eval := range pos.Evaluations {
+				if want := i == 0; eval.IsBest != want {
+					t.Errorf("Evaluation %d (%s): IsBest = %v, want %v", i, eval.Move, eval.IsBest, want)
+				}
+			}
+			if pos.Evaluations[0].Move != "19/18, 14/12" {
+				t.Errorf("Best move = %q, want %q", pos.Evaluations[0].Move, "19/18, 14/12")
+			}
+
+			for _, d := range pos.CubeDecisions {
+				if want := d.Action == tt.cubeBest; d.IsBest != want {
+					t.Errorf("Decision %q IsBest = %v, want %v", d.Action, d.IsBest, want)
+				}
+			}
+		})
+	}
+
+	pos, err := bgfparser.ParseTXT("test/fixtures/checkmark_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if got := pos.Evaluations[1].Move; got != "19/18, 3/1*" {
+		t.Errorf("Hitting move = %q, want %q", got, "19/18, 3/1*")
+	}
+}
+
+func TestParseTXT_PlayerRatings(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/ratings_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	if pos.RatingX != 1712.4 || pos.ExperienceX != 850 {
+		t.Errorf("X rating = %v/%d, want 1712.4/850", pos.RatingX, pos.ExperienceX)
+	}
+	if pos.RatingO != 1650.5 || pos.ExperienceO != 420 {
+		t.Errorf("O rating = %v/%d, want 1650.5/420", pos.RatingO, pos.ExperienceO)
+	}
+
+	// Names and pip counts are unaffected by the ratings
+	if pos.PlayerX != "Red" || pos.PlayerO != "Green" {
+		t.Errorf("Players = %q / %q, want Red / Green", pos.PlayerX, pos.PlayerO)
+	}
+	if pos.PipCount["X"] != 111 || pos.PipCount["O"] != 52 {
+		t.Errorf("PipCount = %v, want X:111 O:52", pos.PipCount)
+	}
+
+	// Ratings are absent from the plain export
+	plain, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if plain.RatingX != 0 || plain.RatingO != 0 || plain.ExperienceX != 0 || plain.ExperienceO != 0 {
+		t.Errorf("Unexpected ratings: %+v", plain)
+	}
+}
+
+func TestParseTXT_Hero(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/hero_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if pos.Hero != "X" {
+		t.Errorf("Hero = %q, want X", pos.Hero)
+	}
+
+	// The marker is not part of the name and leaves the rating readable
+	if pos.PlayerX != "Red" || pos.RatingX != 1712.4 || pos.PipCount["X"] != 111 {
+		t.Errorf("X = %q (%v) %d pips, want Red (1712.4) 111 pips", pos.PlayerX, pos.RatingX, pos.PipCount["X"])
+	}
+
+	plain, err := bgfparser.ParseTXT("test/fixtures/ratings_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if plain.Hero != "" {
+		t.Errorf("Hero without marker = %q, want empty", plain.Hero)
+	}
+}
+
+func TestParseTXT_PercentProbabilities(t *testing.T) {
+	fractions, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	percents, err := bgfparser.ParseTXT("test/fixtures/probabilities_percent_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	if len(percents.Evaluations) != len(fractions.Evaluations) {
+		t.Fatalf("Got %d evaluations, want %d", len(percents.Evaluations), len(fractions.Evaluations))
+	}
+
+	const epsilon = 0.0005
+	for i, got := range percents.Evaluations {
+		want := fractions.Evaluations[i]
+		pairs := [][2]float64{
+			{got.Win, want.Win}, {got.WinG, want.WinG}, {got.WinBG, want.WinBG},
+			{got.LoseG, want.LoseG}, {got.LoseBG, want.LoseBG},
+		}
+		for _, p := range pairs {
+			if math.Abs(p[0]-p[1]) > epsilon {
+				t.Errorf("Evaluation %d: probabilities %+v, want %+v", i+1, got, want)
+				break
+			}
+		}
+	}
+
+	if percents.Evaluations[0].Win != 0.254 {
+		t.Errorf("Win = %v, want 0.254", percents.Evaluations[0].Win)
+	}
+}
+
+func TestParseTXT_BarAndOff(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		onBar map[string]int
+		off   map[string]int
+	}{
+		{"English labels", "test/fixtures/bar_off_EN.txt", map[string]int{"X": 1, "O": 0}, map[string]int{"X": 3, "O": 5}},
+		{"French labels", "test/fixtures/bar_off_FR.txt", map[string]int{"X": 0, "O": 2}, map[string]int{"X": 4, "O": 0}},
+		{"Derived from XGID", "test/2025-11-04/01_checkerPosition_EN.txt", map[string]int{"X": 0, "O": 0}, map[string]int{"X": 0, "O": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXT(tt.file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+
+			for _, player := range []string{"X", "O"} {
+				if pos.OnBar[player] != tt.onBar[player] {
+					t.Errorf("OnBar[%s] = %d, want %d", player, pos.OnBar[player], tt.onBar[player])
+				}
+				if pos.Off[player] != tt.off[player] {
+					t.Errorf("Off[%s] = %d, want %d", player, pos.Off[player], tt.off[player])
+				}
+			}
+		})
+	}
+}
+
+func TestParseTXT_RolloutConfidenceIntervals(t *testing.T) {
+	rollout, err := bgfparser.ParseTXT("test/fixtures/rollout_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	plain, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	if len(rollout.Evaluations) != len(plain.Evaluations) {
+		t.Fatalf("Got %d evaluations, want %d", len(rollout.Evaluations), len(plain.Evaluations))
+	}
+
+	wantCI := []float64{0.012, 0.015, 0, 0, 0}
+	for i, eval := range rollout.Evaluations {
+		if eval.EquityCI != wantCI[i] {
+			t.Errorf("Evaluation %d: EquityCI = %v, want %v", i+1, eval.EquityCI, wantCI[i])
+		}
+
+		// Everything else matches the plain evaluation
+		eval.EquityCI = 0
+		if eval != plain.Evaluations[i] {
+			t.Errorf("Evaluation %d = %+v, want %+v", i+1, eval, plain.Evaluations[i])
+		}
+	}
+
+	for i, eval := range plain.Evaluations {
+		if eval.EquityCI != 0 {
+			t.Errorf("Plain evaluation %d: EquityCI = %v, want 0", i+1, eval.EquityCI)
+		}
+	}
+}
+
+func TestParseTXT_WrappedMoves(t *testing.T) {
+	plain, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	tests := []struct {
+		file  string
+		moves []string
+	}{
+		{"test/fixtures/wrapped_move_EN.txt", []string{"19/18, 14/12", "19/18, 3/1", "19/17, 18/17"}},
+		{"test/fixtures/wrapped_move_dash_EN.txt", []string{"19-18, 14-12", "19-18, 3-1", "19-17, 18-17"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			wrapped, err := bgfparser.ParseTXT(tt.file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+			if len(wrapped.Evaluations) != 3 {
+				t.Fatalf("Got %d evaluations, want 3", len(wrapped.Evaluations))
+			}
+
+			for i, eval := range wrapped.Evaluations {
+				if eval.Move != tt.moves[i] {
+					t.Errorf("Evaluation %d: Move = %q, want %q", i+1, eval.Move, tt.moves[i])
+				}
+				// The probability line after a wrapped move is still parsed
+				want := plain.Evaluations[i]
+				want.Move = tt.moves[i]
+				if eval != want {
+					t.Errorf("Evaluation %d = %+v, want %+v", i+1, eval, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPosition_ColorOf(t *testing.T) {
+	tests := []struct {
+		lang   string
+		colorX string
+		colorO string
+	}{
+		{"EN", "Red", "Green"},
+		{"FR", "Rouge", "Vert"},
+		{"DE", "Rot", "Grün"},
+		{"JP", "赤", "緑"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_" + tt.lang + ".txt")
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+
+			if got := pos.ColorOf("X"); got != tt.colorX {
+				t.Errorf("ColorOf(X) = %q, want %q", got, tt.colorX)
+			}
+			if got := pos.ColorOf("O"); got != tt.colorO {
+				t.Errorf("ColorOf(O) = %q, want %q", got, tt.colorO)
+			}
+
+			// Colors resolve to players in any language
+			for _, color := range []string{"red", "Rouge", "rot", "赤"} {
+				if got := pos.PlayerOfColor(color); got != "X" {
+					t.Errorf("PlayerOfColor(%q) = %q, want X", color, got)
+				}
+			}
+			if got := pos.PlayerOfColor("Vert"); got != "O" {
+				t.Errorf("PlayerOfColor(Vert) = %q, want O", got)
+			}
+		})
+	}
+
+	named := &bgfparser.Position{PlayerX: "Alice", PlayerO: "Bob"}
+	if named.ColorOf("X") != "" || named.PlayerOfColor("red") != "" {
+		t.Error("Expected no colors for named players")
+	}
+}
+
+func TestParseTXT_CubeDecisionDiffForms(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		mwc     float64
+		mwcDiff float64
+		emg     float64
+		emgDiff float64
+	}{
+		{"Zero with space", " No Double : 0.226 ( 0.000) 0.287 ( 0.000)", 0.226, 0, 0.287, 0},
+		{"Negative", " No Double : 0.407 (-0.003) 0.585 (-0.040)", 0.407, -0.003, 0.585, -0.040},
+		{"Negative with space", " No Double : 0.407 ( -0.003) 0.585 ( -0.040)", 0.407, -0.003, 0.585, -0.040},
+		{"Space after sign", " No Double : 0.407 (- 0.003) 0.585 (- 0.040)", 0.407, -0.003, 0.585, -0.040},
+		{"Plus with space", " Double / Pass : 0.433 ( +0.024) 1.000 ( +0.375)", 0.433, 0.024, 1.000, 0.375},
+		{"Plus four decimals", " Double / Pass : 0.4331 (+0.0241) 1.0000 (+0.3750)", 0.4331, 0.0241, 1.000, 0.375},
+		{"Plus zero", " Double / Take : 0.410 ( +0.000) 0.625 (+0.0000)", 0.410, 0, 0.625, 0},
+		{"Negative EMG", " No Double : 0.310 ( 0.000) -0.120 ( 0.000)", 0.310, 0, -0.120, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXTFromReader(strings.NewReader(" Cube Action: : No Double EMG\n" + tt.line + "\n"))
+			if err != nil {
+				t.Fatalf("ParseTXTFromReader failed: %v", err)
+			}
+			if len(pos.CubeDecisions) != 1 {
+				t.Fatalf("Got %d cube decisions, want 1", len(pos.CubeDecisions))
+			}
+
+			d := pos.CubeDecisions[0]
+			if d.MWC != tt.mwc || d.MWCDiff != tt.mwcDiff || d.EMG != tt.emg || d.EMGDiff != tt.emgDiff {
+				t.Errorf("MWC/MWCDiff/EMG/EMGDiff = %v/%v/%v/%v, want %v/%v/%v/%v",
+					d.MWC, d.MWCDiff, d.EMG, d.EMGDiff, tt.mwc, tt.mwcDiff, tt.emg, tt.emgDiff)
+			}
+		})
+	}
+}
+
+func TestParseTXT_RulesFooter(t *testing.T) {
+	tests := []struct {
+		file string
+		want bgfparser.Rules
+	}{
+		{"test/fixtures/rules_EN.txt", bgfparser.Rules{Crawford: true, Beavers: true, AutoDouble: true}},
+		{"test/fixtures/rules_FR.txt", bgfparser.Rules{Crawford: true, Jacoby: true}},
+		{"test/2025-11-04/01_checkerPosition_EN.txt", bgfparser.Rules{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXT(tt.file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+			if pos.Rules != tt.want {
+				t.Errorf("Rules = %+v, want %+v", pos.Rules, tt.want)
+			}
+			if len(pos.Evaluations) != 5 {
+				t.Errorf("Got %d evaluations, want 5", len(pos.Evaluations))
+			}
+		})
+	}
+}
+
+func TestParseTXT_CommaDecimals(t *testing.T) {
+	tests := []struct {
+		name  string
+		comma string
+		dot   string
+	}{
+		{"German checker play", "test/fixtures/comma_decimals_DE.txt", "test/2025-11-04/01_checkerPosition_DE.txt"},
+		{"French cube decision", "test/fixtures/comma_decimals_FR.txt", "test/2025-11-04/03_DT_FR.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comma, err := bgfparser.ParseTXT(tt.comma)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+			dot, err := bgfparser.ParseTXT(tt.dot)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+
+			if len(dot.Evaluations) == 0 && len(dot.CubeDecisions) == 0 {
+				t.Fatal("Dot-decimal fixture has no analysis to compare")
+			}
+			if !reflect.DeepEqual(comma.Evaluations, dot.Evaluations) {
+				t.Errorf("Evaluations = %+v, want %+v", comma.Evaluations, dot.Evaluations)
+			}
+			if !reflect.DeepEqual(comma.CubeDecisions, dot.CubeDecisions) {
+				t.Errorf("CubeDecisions = %+v, want %+v", comma.CubeDecisions, dot.CubeDecisions)
+			}
+			if comma.CubelessEquity != dot.CubelessEquity || comma.CubefulEquity != dot.CubefulEquity ||
+				comma.EquityStdDev != dot.EquityStdDev {
+				t.Errorf("Equities = %v/%v/%v, want %v/%v/%v",
+					comma.CubelessEquity, comma.CubefulEquity, comma.EquityStdDev,
+					dot.CubelessEquity, dot.CubefulEquity, dot.EquityStdDev)
+			}
+		})
+	}
+
+	// Two-decimal values are converted in a file using decimal commas
+	pos, err := bgfparser.ParseTXTFromReader(strings.NewReader("Bewertung\n==========\n" +
+		" 1) 19/18 14/12    0,47 / -0,12\n" +
+		" 2) 19/18 3/1      0,45 / -0,16  (-0,04)\n"))
+	if err != nil {
+		t.Fatalf("ParseTXTFromReader failed: %v", err)
+	}
+	if len(pos.Evaluations) != 2 || pos.Evaluations[0].Equity != -0.12 || pos.Evaluations[1].Diff != -0.04 {
+		t.Errorf("Evaluations = %+v, want equities -0.12 and -0.16", pos.Evaluations)
+	}
+
+	// Thousands separators are kept in a file using decimal dots
+	pos, err = bgfparser.ParseTXTFromReader(strings.NewReader("Evaluation\n==========\n" +
+		" 1) 19/18 14/12    0.124 / -0.492  # seen in 1,234 games\n"))
+	if err != nil {
+		t.Fatalf("ParseTXTFromReader failed: %v", err)
+	}
+	if len(pos.Evaluations) != 1 || pos.Evaluations[0].Comment != "seen in 1,234 games" || pos.Evaluations[0].Equity != -0.492 {
+		t.Errorf("Evaluations = %+v, want the comment kept as is", pos.Evaluations)
+	}
+}
+
+func TestParseTXT_Kind(t *testing.T) {
+	tests := []struct {
+		file string
+		kind string
+	}{
+		{"test/2025-11-04/01_checkerPosition_EN.txt", "checker"},
+		{"test/2025-11-04/01_checkerPosition_JP.txt", "checker"},
+		{"test/2025-11-04/03_DT_EN.txt", "cube"},
+		{"test/2025-11-04/04_DP_DE.txt", "cube"},
+		{"test/fixtures/checker_and_cube_EN.txt", "both"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXT(tt.file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+			if pos.Kind != tt.kind {
+				t.Errorf("Kind = %q, want %q", pos.Kind, tt.kind)
+			}
+			if hasChecker := tt.kind != "cube"; (len(pos.Evaluations) > 0) != hasChecker {
+				t.Errorf("Got %d evaluations for kind %q", len(pos.Evaluations), tt.kind)
+			}
+			if hasCube := tt.kind != "checker"; (len(pos.CubeDecisions) > 0) != hasCube {
+				t.Errorf("Got %d cube decisions for kind %q", len(pos.CubeDecisions), tt.kind)
+			}
+		})
+	}
+
+	// A file with a board but no analysis has no kind
+	pos := parseXGIDPosition(t, "-B-CBBB---a---A---ABcbbbd-:1:-1:1:21:3:6:0:7:10")
+	if pos.Kind != "" {
+		t.Errorf("Kind = %q, want empty", pos.Kind)
+	}
+}
+
+func TestParseTXT_DiceAfterScore(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/dice_after_score_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if pos.Dice != [2]int{1, 2} {
+		t.Errorf("Dice = %v, want [1 2]", pos.Dice)
+	}
+	if pos.OnRoll != "X" {
+		t.Errorf("OnRoll = %q, want X", pos.OnRoll)
+	}
+
+	tests := []struct {
+		line string
+		dice [2]int
+	}{
+		{"Red to move 6-5", [2]int{6, 5}},
+		{"Red to move 3,2", [2]int{3, 2}},
+		{"Red to move rolls 3 2", [2]int{3, 2}},
+		{"Red (5-3) to move.", [2]int{}},
+		{"Red to move 7-2", [2]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXTFromReader(strings.NewReader(tt.line + "\n"))
+			if err != nil {
+				t.Fatalf("ParseTXTFromReader failed: %v", err)
+			}
+			if pos.Dice != tt.dice {
+				t.Errorf("Dice = %v, want %v", pos.Dice, tt.dice)
+			}
+		})
+	}
+}
+
+func TestParseTXT_MultiWordPlayerNames(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/multiword_names_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	// "Zoé" is written with a combining acute accent
+	if pos.PlayerO != "Jean Pierre" || pos.PlayerX != "Zoé Martin" {
+		t.Errorf("Players = %q / %q, want Jean Pierre / Zoé Martin", pos.PlayerO, pos.PlayerX)
+	}
+	if pos.PipCount["O"] != 52 || pos.PipCount["X"] != 111 {
+		t.Errorf("PipCount = %v, want O:52 X:111", pos.PipCount)
+	}
+	if pos.OnRoll != "X" || pos.ScoreO != 6 || pos.ScoreX != 3 {
+		t.Errorf("OnRoll = %q, score = %d-%d, want X to move at 6-3", pos.OnRoll, pos.ScoreO, pos.ScoreX)
+	}
+	if len(pos.Warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", pos.Warnings)
+	}
+
+	tests := []struct {
+		line    string
+		playerO string
+		pipsO   int
+		ratingO float64
+	}{
+		{`O: "Jean Pierre" 167  X: Red 111`, "Jean Pierre", 167, 0},
+		{"O: Jean Pierre (1650.5/420) 167  X: Red 111", "Jean Pierre", 167, 1650.5},
+		{"O: Player1 150  X: Player2 140", "Player1", 150, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXTFromReader(strings.NewReader(tt.line + "\n"))
+			if err != nil {
+				t.Fatalf("ParseTXTFromReader failed: %v", err)
+			}
+			if pos.PlayerO != tt.playerO || pos.PipCount["O"] != tt.pipsO || pos.RatingO != tt.ratingO {
+				t.Errorf("O = %q with %d pips and rating %v, want %q with %d pips and rating %v",
+					pos.PlayerO, pos.PipCount["O"], pos.RatingO, tt.playerO, tt.pipsO, tt.ratingO)
+			}
+		})
+	}
+}
+
+func TestParseTXT_ForcedPass(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/dance_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if !pos.ForcedPass {
+		t.Error("ForcedPass = false, want true")
+	}
+	if len(pos.Evaluations) != 0 {
+		t.Errorf("Got %d evaluations, want 0", len(pos.Evaluations))
+	}
+	if pos.OnBar["X"] != 1 || pos.Dice != [2]int{6, 3} {
+		t.Errorf("OnBar = %v, Dice = %v, want X on the bar with 6-3", pos.OnBar, pos.Dice)
+	}
+
+	// The board confirms the dance
+	if plays, err := pos.LegalMoves(); err != nil || plays != nil {
+		t.Errorf("LegalMoves() = %v, %v, want no plays", plays, err)
+	}
+
+	for _, line := range []string{"Rouge ne peut pas jouer.", "Rot kann nicht ziehen.", "赤は動けません。"} {
+		pos, err := bgfparser.ParseTXTFromReader(strings.NewReader(line + "\n"))
+		if err != nil {
+			t.Fatalf("ParseTXTFromReader failed: %v", err)
+		}
+		if !pos.ForcedPass {
+			t.Errorf("%q: ForcedPass = false, want true", line)
+		}
+	}
+
+	// Lines only mentioning a marker are parsed as usual
+	for _, tt := range []struct {
+		line  string
+		evals int
+	}{
+		{" 1) 24/18 13/10   0.124 / -0.492  # the back checker cannot move", 1},
+		{"Red cannot move this turn.", 0},
+	} {
+		pos, err := bgfparser.ParseTXTFromReader(strings.NewReader("Evaluation\n==========\n" + tt.line + "\n"))
+		if err != nil {
+			t.Fatalf("ParseTXTFromReader failed: %v", err)
+		}
+		if pos.ForcedPass || len(pos.Evaluations) != tt.evals {
+			t.Errorf("%q: ForcedPass = %v with %d evaluations, want false with %d", tt.line, pos.ForcedPass, len(pos.Evaluations), tt.evals)
+		}
+	}
+
+	// Positions with evaluations are not forced passes
+	plain, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if plain.ForcedPass {
+		t.Error("ForcedPass = true for a position with evaluations")
+	}
+}
+
+func TestParseTXT_PipCountLine(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/pip_line_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if pos.PipCount["X"] != 111 || pos.PipCount["O"] != 52 {
+		t.Errorf("PipCount = %v, want X:111 O:52", pos.PipCount)
+	}
+	if pos.PlayerX != "Red" || pos.PlayerO != "Green" {
+		t.Errorf("Players = %q / %q, want Red / Green", pos.PlayerX, pos.PlayerO)
+	}
+	if len(pos.Warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", pos.Warnings)
+	}
+
+	tests := []struct {
+		name     string
+		txt      string
+		pips     map[string]int
+		warnings int
+	}{
+		{"Localized label", "Compte de pips: O=52 X=111\n", map[string]int{"X": 111, "O": 52}, 0},
+		{"Header only for O", "O: Green 52\nX: Red\nPip: X 111 O 52\n", map[string]int{"X": 111, "O": 52}, 0},
+		{"Header wins on conflict", "O: Green 52  X: Red 111\nPip: X 110 O 52\n", map[string]int{"X": 111, "O": 52}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXTFromReader(strings.NewReader(tt.txt))
+			if err != nil {
+				t.Fatalf("ParseTXTFromReader failed: %v", err)
+			}
+			if pos.PipCount["X"] != tt.pips["X"] || pos.PipCount["O"] != tt.pips["O"] {
+				t.Errorf("PipCount = %v, want %v", pos.PipCount, tt.pips)
+			}
+			if len(pos.Warnings) != tt.warnings {
+				t.Errorf("Warnings = %v, want %d", pos.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestParseTXT_MalformedNumber(t *testing.T) {
+	data, err := os.ReadFile("test/fixtures/corrupt_equity_EN.txt")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	pos, err := bgfparser.ParseTXTFromReader(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("ParseTXTFromReader failed: %v", err)
+	}
+	if len(pos.Evaluations) != 5 {
+		t.Fatalf("Expected 5 evaluations, got %d", len(pos.Evaluations))
+	}
+	if pos.Evaluations[1].Equity != 0 {
+		t.Errorf("Malformed equity = %v, want 0", pos.Evaluations[1].Equity)
+	}
+	if len(pos.Warnings) != 0 {
+		t.Errorf("Warnings = %q, want none outside strict mode", pos.Warnings)
+	}
+
+	opts := bgfparser.TXTOptions{Strict: true}
+	_, err = bgfparser.ParseTXTFromReaderWithOptions(strings.NewReader(string(data)), opts)
+	var parseErr *bgfparser.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a ParseError in strict mode, got %v", err)
+	}
+	if parseErr.Line != 26 {
+		t.Errorf("ParseError.Line = %d, want 26", parseErr.Line)
+	}
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) || numErr.Num != "-0.5x5" {
+		t.Errorf("Expected the error to wrap a NumError for \"-0.5x5\", got %v", err)
+	}
+
+	// Well-formed files parse the same in strict mode
+	for _, file := range []string{"test/fixtures/rollout_EN.txt", "test/fixtures/diff_cube_EN.txt"} {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file, err)
+		}
+		_, err = bgfparser.ParseTXTFromReaderWithOptions(f, opts)
+		f.Close()
+		if err != nil {
+			t.Errorf("%s: strict parse failed: %v", file, err)
+		}
+	}
+}
+
+func TestParseTXT_Comments(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/comments_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	want := "Red trails 3-6 and must keep the gammon chances low.\n" +
+		"  19/18 unstacks the back checkers.\n" +
+		"\n" +
+		"Played 19/18, 14/12 in the match.\n" +
+		"Reviewed after the match."
+	if pos.Comment != want {
+		t.Errorf("Comment = %q, want %q", pos.Comment, want)
+	}
+
+	if len(pos.Evaluations) != 5 {
+		t.Fatalf("Expected 5 evaluations, got %d", len(pos.Evaluations))
+	}
+	best := pos.Evaluations[0]
+	if best.Comment != "safest play" || best.Move != "19/18, 14/12" || best.Equity != -0.492 {
+		t.Errorf("First evaluation = %q %q %v, want comment \"safest play\" on 19/18, 14/12 at -0.492",
+			best.Comment, best.Move, best.Equity)
+	}
+	if c := pos.Evaluations[1].Comment; c != "" {
+		t.Errorf("Second evaluation comment = %q, want none", c)
+	}
+
+	// The rest of the position is unaffected
+	if pos.PlayerX != "Red" || pos.OnRoll != "X" || pos.Dice != (bgfparser.Dice{1, 2}) {
+		t.Errorf("Position = %s on roll %s with %v, want Red on roll X with 1-2", pos.PlayerX, pos.OnRoll, pos.Dice)
+	}
+}
+
+func TestParseTXT_SingleLineEvaluations(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/single_line_eval_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	twoLines, err := bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if !reflect.DeepEqual(pos.Evaluations, twoLines.Evaluations) {
+		t.Errorf("Evaluations = %+v, want %+v", pos.Evaluations, twoLines.Evaluations)
+	}
+	best := pos.Evaluations[0]
+	if best.Move != "19/18, 14/12" || best.Equity != -0.492 || best.Win != 0.254 || best.LoseG != 0.338 {
+		t.Errorf("First evaluation = %+v, want 19/18, 14/12 at -0.492 with probabilities", best)
+	}
+
+	// Percentages after the equity in the older layout
+	txt := "Evaluation\n 1) 13-11 24-23   0.473 / -0.289   44.3% 11.3% 0.2% - 55.7% 17.9% 0.3%\n"
+	pos, err = bgfparser.ParseTXTFromReader(strings.NewReader(txt))
+	if err != nil {
+		t.Fatalf("ParseTXTFromReader failed: %v", err)
+	}
+	if len(pos.Evaluations) != 1 {
+		t.Fatalf("Expected 1 evaluation, got %d", len(pos.Evaluations))
+	}
+	eval := pos.Evaluations[0]
+	if eval.Move != "13-11 24-23" || eval.Equity != -0.289 ||
+		math.Abs(eval.Win-0.443) > 1e-9 || math.Abs(eval.LoseBG-0.003) > 1e-9 {
+		t.Errorf("Evaluation = %+v, want 13-11 24-23 at -0.289 with win 0.443 and lose BG 0.003", eval)
+	}
+}
+
+func TestParseTXT_RollEquities(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/roll_equities_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if len(pos.RollEquities) != 21 {
+		t.Fatalf("Got %d roll equities, want 21: %v", len(pos.RollEquities), pos.RollEquities)
+	}
+	for roll, want := range map[string]float64{"1-1": -0.589, "2-1": -0.55, "5-3": 0, "6-5": 0.35, "6-6": 0.411} {
+		if got, ok := pos.RollEquities[roll]; !ok || got != want {
+			t.Errorf("RollEquities[%s] = %v, %v; want %v", roll, got, ok, want)
+		}
+	}
+	if len(pos.Evaluations) != 5 {
+		t.Errorf("Expected 5 evaluations, got %d", len(pos.Evaluations))
+	}
+
+	// Localized header, lower die first and decimal commas
+	txt := "Équités par lancer\n 1-2 : 0,125   5-6 : -0,250\n"
+	pos, err = bgfparser.ParseTXTFromReader(strings.NewReader(txt))
+	if err != nil {
+		t.Fatalf("ParseTXTFromReader failed: %v", err)
+	}
+	want := map[string]float64{"2-1": 0.125, "6-5": -0.25}
+	if !reflect.DeepEqual(pos.RollEquities, want) {
+		t.Errorf("RollEquities = %v, want %v", pos.RollEquities, want)
+	}
+
+	// No table
+	pos, err = bgfparser.ParseTXT("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if pos.RollEquities != nil {
+		t.Errorf("RollEquities = %v, want nil", pos.RollEquities)
+	}
+}
+
+func TestParseTXT_LineEndings(t *testing.T) {
+	data, err := os.ReadFile("test/2025-11-04/01_checkerPosition_EN.txt")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	want, err := bgfparser.ParseTXTFromReader(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("ParseTXTFromReader failed: %v", err)
+	}
+
+	cr, err := bgfparser.ParseTXT("test/fixtures/cr_line_endings_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if !reflect.DeepEqual(cr, want) {
+		t.Errorf("CR-only file parsed as %+v, want %+v", cr, want)
+	}
+
+	// CRLF, and a mix of all three line endings, read a byte at a time so
+	// that a "\r" may end the data read so far
+	lines := strings.Split(string(data), "\n")
+	var mixed strings.Builder
+	for i, line := range lines {
+		mixed.WriteString(line)
+		if i < len(lines)-1 {
+			mixed.WriteString([]string{"\r\n", "\r", "\n"}[i%3])
+		}
+	}
+	for name, txt := range map[string]string{
+		"CRLF":  strings.ReplaceAll(string(data), "\n", "\r\n"),
+		"Mixed": mixed.String(),
+	} {
+		got, err := bgfparser.ParseTXTFromReader(iotest.OneByteReader(strings.NewReader(txt)))
+		if err != nil {
+			t.Fatalf("%s: ParseTXTFromReader failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: parsed as %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestParseTXT_DeadLiveCube(t *testing.T) {
+	for _, file := range []string{"test/fixtures/cube_life_EN.txt", "test/fixtures/cube_life_FR.txt"} {
+		t.Run(file, func(t *testing.T) {
+			pos, err := bgfparser.ParseTXT(file)
+			if err != nil {
+				t.Fatalf("ParseTXT failed: %v", err)
+			}
+			if pos.DeadCubeEquity != 0.344 || pos.LiveCubeEquity != 0.452 || pos.RecubeVig != 0.042 {
+				t.Errorf("Dead/live/vig = %v/%v/%v, want 0.344/0.452/0.042",
+					pos.DeadCubeEquity, pos.LiveCubeEquity, pos.RecubeVig)
+			}
+			if pos.CubefulEquity != 0.41 || len(pos.CubeDecisions) != 3 {
+				t.Errorf("Cubeful equity %v with %d cube decisions, want 0.41 with 3",
+					pos.CubefulEquity, len(pos.CubeDecisions))
+			}
+		})
+	}
+
+	pos, err := bgfparser.ParseTXT("test/2025-11-04/03_DT_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if pos.DeadCubeEquity != 0 || pos.LiveCubeEquity != 0 || pos.RecubeVig != 0 {
+		t.Errorf("Dead/live/vig = %v/%v/%v, want zero without the lines",
+			pos.DeadCubeEquity, pos.LiveCubeEquity, pos.RecubeVig)
+	}
+}
+
+// TestParseTXT_XG tests that XG position text fills the same Position as
+// BGBlitz exports
+func TestParseTXT_XG(t *testing.T) {
+	pos, err := bgfparser.ParseTXT("test/fixtures/xg_checker_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+
+	if pos.PlayerX != "Red" || pos.PlayerO != "Green" {
+		t.Errorf("Players = %q / %q, want Red / Green", pos.PlayerX, pos.PlayerO)
+	}
+	if pos.ScoreX != 2 || pos.ScoreO != 1 || pos.MatchLength != 5 {
+		t.Errorf("Score = %d-%d/%d, want 2-1/5", pos.ScoreX, pos.ScoreO, pos.MatchLength)
+	}
+	if pos.OnRoll != "X" || pos.Dice != (bgfparser.Dice{5, 2}) {
+		t.Errorf("OnRoll/Dice = %q %v, want X 5-2", pos.OnRoll, pos.Dice)
+	}
+	if pos.PipCount["X"] != 167 || pos.PipCount["O"] != 167 {
+		t.Errorf("PipCount = %v, want 167/167", pos.PipCount)
+	}
+	if pos.Engine != "eXtreme Gammon 2.19.211" || pos.Settings != "XG Roller+" {
+		t.Errorf("Engine/Settings = %q / %q", pos.Engine, pos.Settings)
+	}
+	if pos.Kind != "checker" {
+		t.Errorf("Kind = %q, want checker", pos.Kind)
+	}
+
+	wantMoves := []string{"13/11 13/8", "24/22 13/8", "13/8 6/4", "24/17"}
+	if len(pos.Evaluations) != len(wantMoves) {
+		t.Fatalf("Expected %d evaluations, got %d", len(wantMoves), len(pos.Evaluations))
+	}
+	for i, eval := range pos.Evaluations {
+		if eval.Rank != i+1 || eval.Move != wantMoves[i] {
+			t.Errorf("Evaluation %d = %d %q, want %d %q", i, eval.Rank, eval.Move, i+1, wantMoves[i])
+		}
+	}
+	second := pos.Evaluations[1]
+	if second.Equity != -0.015 || second.Diff != -0.007 {
+		t.Errorf("Evaluation 2 equity/diff = %v/%v, want -0.015/-0.007", second.Equity, second.Diff)
+	}
+	if math.Abs(second.Win-0.4978) > 1e-9 || math.Abs(second.WinG-0.1335) > 1e-9 ||
+		math.Abs(second.LoseG-0.1345) > 1e-9 || math.Abs(second.LoseBG-0.0056) > 1e-9 {
+		t.Errorf("Evaluation 2 probabilities = %+v", second)
+	}
+	if !pos.Evaluations[0].IsBest {
+		t.Error("First evaluation not flagged best")
+	}
+
+	pos, err = bgfparser.ParseTXT("test/fixtures/xg_cube_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if pos.CubeValue != 2 || pos.CubeOwner != "X" || pos.OnRoll != "X" {
+		t.Errorf("Cube/OnRoll = %d %q %q, want 2 X X", pos.CubeValue, pos.CubeOwner, pos.OnRoll)
+	}
+	if pos.Kind != "cube" || pos.Recommendation != "Redouble / Take" || pos.CubelessEquity != 0.362 {
+		t.Errorf("Kind/Recommendation/Cubeless = %q / %q / %v", pos.Kind, pos.Recommendation, pos.CubelessEquity)
+	}
+	want := []bgfparser.CubeDecision{
+		{Action: "No redouble", EMG: 0.512, EMGDiff: -0.116},
+		{Action: "Redouble/Take", EMG: 0.628, IsBest: true},
+		{Action: "Redouble/Pass", EMG: 1.000, EMGDiff: 0.372},
+	}
+	if !reflect.DeepEqual(pos.CubeDecisions, want) {
+		t.Errorf("CubeDecisions = %+v, want %+v", pos.CubeDecisions, want)
+	}
+	if len(pos.Evaluations) != 0 || len(pos.Warnings) != 0 {
+		t.Errorf("Unexpected evaluations %v or warnings %v", pos.Evaluations, pos.Warnings)
+	}
+}
+
+// TestParseTXT_ImplausibleProbabilities tests the warnings about probabilities
+// out of range or with more gammons than wins, cleared in strict mode
+func TestParseTXT_ImplausibleProbabilities(t *testing.T) {
+	data, err := os.ReadFile("test/fixtures/bad_probabilities_EN.txt")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	pos, err := bgfparser.ParseTXTFromReader(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("ParseTXTFromReader failed: %v", err)
+	}
+	want := []string{
+		"evaluation 2 (19/18, 3/1): implausible probabilities, win_g 0.385 exceeds win 0.227",
+		"evaluation 4 (14/12, 3/2): implausible probabilities, win 2.11 outside [0,1]",
+	}
+	if !reflect.DeepEqual(pos.Warnings, want) {
+		t.Errorf("Warnings = %q, want %q", pos.Warnings, want)
+	}
+	if pos.Evaluations[3].Win != 2.11 {
+		t.Errorf("Win = %v, want the value kept outside strict mode", pos.Evaluations[3].Win)
+	}
+
+	opts := bgfparser.TXTOptions{Strict: true}
+	pos, err = bgfparser.ParseTXTFromReaderWithOptions(strings.NewReader(string(data)), opts)
+	if err != nil {
+		t.Fatalf("ParseTXTFromReaderWithOptions failed: %v", err)
+	}
+	for i, w := range want {
+		if i >= len(pos.Warnings) || pos.Warnings[i] != w+", cleared" {
+			t.Errorf("Strict warnings = %q, want %q with \", cleared\"", pos.Warnings, want)
+			break
+		}
+	}
+	for _, i := range []int{1, 3} {
+		e := pos.Evaluations[i]
+		if e.Win != 0 || e.WinG != 0 || e.WinBG != 0 || e.LoseG != 0 || e.LoseBG != 0 {
+			t.Errorf("Evaluation %d probabilities not cleared: %+v", i+1, e)
+		}
+	}
+	if pos.Evaluations[0].Win != 0.254 {
+		t.Errorf("Plausible probabilities changed: Win = %v", pos.Evaluations[0].Win)
+	}
+}
+
+// TestParseTXT_DiceInBoard tests dice drawn on the board, in brackets or as
+// die faces, when the "to move" line has none
+func TestParseTXT_DiceInBoard(t *testing.T) {
+	bracketed, err := bgfparser.ParseTXT("test/fixtures/dice_in_board_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if bracketed.Dice != (bgfparser.Dice{1, 2}) || bracketed.OnRoll != "X" {
+		t.Errorf("Dice/OnRoll = %v %q, want 1-2 X", bracketed.Dice, bracketed.OnRoll)
+	}
+
+	// Without an XGID the board is read around the die faces
+	faces, err := bgfparser.ParseTXT("test/fixtures/dice_glyphs_EN.txt")
+	if err != nil {
+		t.Fatalf("ParseTXT failed: %v", err)
+	}
+	if faces.Dice != (bgfparser.Dice{1, 2}) {
+		t.Errorf("Dice = %v, want 1-2", faces.Dice)
+	}
+	if faces.Board != bracketed.Board {
+		t.Errorf("Board = %v, want %v", faces.Board, bracketed.Board)
+	}
+}
@@ -0,0 +1,181 @@
+package bgfparser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// XG position text shares the board and XGID line with BGBlitz exports but
+// lays out names, scores, evaluations and cube analysis differently:
+//
+//	X:Red   O:Green
+//	Score is X:0 O:0 5 pt.(s) match.
+//	Pip count  X: 167  O: 167 X-O: 0-0/5
+//	X to play 52
+//
+//	    1. XG Roller+  13/11 13/8                  eq:-0.008
+//	      Player:   49.93% (G:13.77% B:0.55%)
+//	      Opponent: 50.07% (G:13.27% B:0.53%)
+//
+//	Cubeful Equities:
+//	       No redouble:     +0.980 (-0.020)
+//	Best Cube action: Redouble / Pass
+
+var (
+	// xgPlayersRe captures both names, e.g. "X:Red   O:Green"
+	xgPlayersRe = regexp.MustCompile(`^\s*X:\s*(\S.*?)\s{2,}O:\s*(\S.*?)\s*$`)
+
+	// xgScoreRe captures the score and the match length, absent for money games
+	xgScoreRe = regexp.MustCompile(`^\s*Score is X:\s*(\d+)\s+O:\s*(\d+)(?:[.,]?\s+(\d+)\s*pt)?`)
+
+	// xgPipRe captures both pip counts, e.g. "Pip count  X: 167  O: 167 X-O: 0-0/5"
+	xgPipRe = regexp.MustCompile(`^\s*Pip count\s+X:\s*(\d+)\s+O:\s*(\d+)`)
+
+	// xgCubeRe captures the cube value and its owner, e.g. "Cube: 2, X own cube"
+	xgCubeRe = regexp.MustCompile(`^\s*Cube:\s*(\d+)(?:,\s*([XO]) owns? cube)?\s*$`)
+
+	// xgTurnRe captures the player on roll and the dice, absent for a cube action
+	xgTurnRe = regexp.MustCompile(`^\s*([XO]) (?:to play (\d)(\d)|on roll, cube action|to roll)`)
+
+	// xgMoveRe captures an evaluated move: rank, analysis level and move, equity and diff
+	xgMoveRe = regexp.MustCompile(`^\s*(\d+)\.\s+(.+?)\s+eq:\s*([+-]?\d+\.\d+)(?:\s*\(\s*([+-]?\d+\.\d+)\s*\))?\s*$`)
+
+	// xgChancesRe captures the winning, gammon and backgammon chances of a side,
+	// after an evaluated move or ("Player Winning Chances:") a cube action
+	xgChancesRe = regexp.MustCompile(`^\s*(Player|Opponent)(?: Winning Chances)?:\s+(\d+(?:\.\d+)?)%\s*\(G:\s*(\d+(?:\.\d+)?)%\s+B:\s*(\d+(?:\.\d+)?)%\)`)
+
+	// xgCubelessRe captures the cubeless equity without doubling
+	xgCubelessRe = regexp.MustCompile(`^\s*Cubeless Equities:\s*No (?:re)?double\s*=\s*([+-]?\d+\.\d+)`)
+
+	// xgCubeOptionRe captures a cubeful equity line, e.g. "Redouble/Take:   +1.374 (+0.374)"
+	xgCubeOptionRe = regexp.MustCompile(`^\s*([A-Za-z][A-Za-z /]*?):\s+([+-]?\d+\.\d+)(?:\s*\(\s*([+-]?\d+\.\d+)\s*\))?\s*$`)
+
+	// xgBestCubeRe captures the recommended cube action
+	xgBestCubeRe = regexp.MustCompile(`^\s*Best Cube action:\s*(.+?)\s*$`)
+
+	// xgLevelRe captures the analysis level of a cube action, e.g. "Analyzed in XG Roller++"
+	xgLevelRe = regexp.MustCompile(`^\s*Analyzed in\s+(.+?)\s*$`)
+
+	// xgVersionRe captures the XG version footer
+	xgVersionRe = regexp.MustCompile(`^\s*eXtreme Gammon Version:\s*(\S+)`)
+)
+
+// xgState tracks the analysis sections of an XG position text being read
+type xgState struct {
+	lastEval      *Evaluation // Evaluation awaiting its chances lines
+	inCubeful     bool        // Inside the "Cubeful Equities:" list
+	checker, cube bool        // Analysis sections seen
+}
+
+// parseXGLine parses the lines specific to XG position text into pos,
+// reporting whether the line was consumed. printedDiffs records whether
+// each evaluation added printed its diff.
+func parseXGLine(line string, xg *xgState, pos *Position, printedDiffs *[]bool) bool {
+	if m := xgPlayersRe.FindStringSubmatch(line); m != nil {
+		pos.PlayerX, pos.PlayerO = m[1], m[2]
+		return true
+	}
+	if m := xgScoreRe.FindStringSubmatch(line); m != nil {
+		pos.ScoreX, _ = strconv.Atoi(m[1])
+		pos.ScoreO, _ = strconv.Atoi(m[2])
+		pos.MatchLength, _ = strconv.Atoi(m[3])
+		// Money games list the rules in use, e.g. "Unlimited Game, Jacoby Beaver"
+		pos.Rules.Jacoby = pos.Rules.Jacoby || strings.Contains(line, "Jacoby")
+		pos.Rules.Beavers = pos.Rules.Beavers || strings.Contains(line, "Beaver")
+		return true
+	}
+	if m := xgPipRe.FindStringSubmatch(line); m != nil {
+		pos.PipCount["X"], _ = strconv.Atoi(m[1])
+		pos.PipCount["O"], _ = strconv.Atoi(m[2])
+		return true
+	}
+	if m := xgCubeRe.FindStringSubmatch(line); m != nil {
+		pos.CubeValue, _ = strconv.Atoi(m[1])
+		pos.CubeOwner = m[2]
+		return true
+	}
+	if m := xgTurnRe.FindStringSubmatch(line); m != nil {
+		pos.OnRoll = m[1]
+		if m[2] != "" {
+			pos.Dice[0], _ = strconv.Atoi(m[2])
+			pos.Dice[1], _ = strconv.Atoi(m[3])
+		}
+		return true
+	}
+
+	if m := xgMoveRe.FindStringSubmatch(line); m != nil {
+		// The analysis level ("XG Roller+", "3-ply") precedes the move
+		fields := strings.Fields(m[2])
+		start := len(fields)
+		for i, f := range fields {
+			if strings.Contains(f, "/") {
+				start = i
+				break
+			}
+		}
+		if pos.Settings == "" && len(pos.Evaluations) == 0 {
+			pos.Settings = strings.Join(fields[:start], " ")
+		}
+
+		eval := Evaluation{Rank: len(pos.Evaluations) + 1, Move: strings.Join(fields[start:], " ")}
+		eval.PrintedRank, _ = strconv.Atoi(m[1])
+		eval.Equity, _ = strconv.ParseFloat(m[3], 64)
+		if m[4] != "" {
+			eval.Diff, _ = strconv.ParseFloat(m[4], 64)
+		}
+		pos.Evaluations = append(pos.Evaluations, eval)
+		*printedDiffs = append(*printedDiffs, m[4] != "")
+		xg.lastEval = &pos.Evaluations[len(pos.Evaluations)-1]
+		xg.checker = true
+		return true
+	}
+	if m := xgChancesRe.FindStringSubmatch(line); m != nil {
+		if xg.lastEval != nil {
+			g, _ := strconv.ParseFloat(m[3], 64)
+			bg, _ := strconv.ParseFloat(m[4], 64)
+			if m[1] == "Player" {
+				win, _ := strconv.ParseFloat(m[2], 64)
+				xg.lastEval.Win, xg.lastEval.WinG, xg.lastEval.WinBG = win/100, g/100, bg/100
+			} else {
+				xg.lastEval.LoseG, xg.lastEval.LoseBG = g/100, bg/100
+			}
+		}
+		return true
+	}
+
+	if m := xgLevelRe.FindStringSubmatch(line); m != nil {
+		if pos.Settings == "" {
+			pos.Settings = m[1]
+		}
+		return true
+	}
+	if m := xgCubelessRe.FindStringSubmatch(line); m != nil {
+		pos.CubelessEquity, _ = strconv.ParseFloat(m[1], 64)
+		return true
+	}
+	if strings.TrimSpace(line) == "Cubeful Equities:" {
+		xg.inCubeful, xg.cube = true, true
+		return true
+	}
+	if m := xgBestCubeRe.FindStringSubmatch(line); m != nil {
+		pos.Recommendation = m[1]
+		xg.inCubeful = false
+		return true
+	}
+	if m := xgCubeOptionRe.FindStringSubmatch(line); m != nil && xg.inCubeful {
+		decision := CubeDecision{Action: m[1]}
+		decision.EMG, _ = strconv.ParseFloat(m[2], 64)
+		if m[3] != "" {
+			decision.EMGDiff, _ = strconv.ParseFloat(m[3], 64)
+		}
+		pos.CubeDecisions = append(pos.CubeDecisions, decision)
+		return true
+	}
+
+	if m := xgVersionRe.FindStringSubmatch(line); m != nil {
+		pos.Engine = "eXtreme Gammon " + m[1]
+		return true
+	}
+	return false
+}
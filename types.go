@@ -24,9 +24,36 @@ type Position struct {
 	ScoreX  int    `json:"score_x"`
 	ScoreO  int    `json:"score_o"`
 
+	// Player ratings and experience (number of matches), when shown next to the names
+	RatingX     float64 `json:"rating_x,omitempty"`
+	RatingO     float64 `json:"rating_o,omitempty"`
+	ExperienceX int     `json:"experience_x,omitempty"`
+	ExperienceO int     `json:"experience_o,omitempty"`
+
+	// Player the file is shown for ("X" or "O"), when a name is marked as "you";
+	// equities are then from that player's perspective
+	Hero string `json:"hero,omitempty"`
+
 	// Match information
-	MatchLength int  `json:"match_length"`
-	Crawford    bool `json:"crawford"`
+	// Crawford and PostCrawford are mutually exclusive: Crawford is set during
+	// the Crawford game itself, PostCrawford for later games where a side is
+	// still at match point. Both are false before either side reaches match point.
+	MatchLength  int  `json:"match_length"`
+	Crawford     bool `json:"crawford"`
+	PostCrawford bool `json:"post_crawford"`
+
+	// Match metadata (only present in some exports)
+	Date  string `json:"date,omitempty"`
+	Event string `json:"event,omitempty"`
+	Site  string `json:"site,omitempty"`
+	Round string `json:"round,omitempty"`
+
+	// Analysis engine (e.g. "BGBlitz 6.7.0") and its settings, when named in the file
+	Engine   string `json:"engine,omitempty"`
+	Settings string `json:"settings,omitempty"`
+
+	// Match rules listed in the rules footer (all false when there is none)
+	Rules Rules `json:"rules"`
 
 	// Position identifiers
 	PositionID string `json:"position_id"` // BGBlitz Position-ID
@@ -35,11 +62,12 @@ type Position struct {
 
 	// Current state
 	OnRoll    string         `json:"on_roll"` // "X" or "O"
-	Dice      [2]int         `json:"dice"`
+	Dice      Dice           `json:"dice"`
 	CubeValue int            `json:"cube_value"`
 	CubeOwner string         `json:"cube_owner"` // "", "X", "O"
 	OnBar     map[string]int `json:"on_bar"`
 	PipCount  map[string]int `json:"pip_count"`
+	Off       map[string]int `json:"off"` // Checkers borne off
 
 	// Evaluation data
 	Evaluations   []Evaluation   `json:"evaluations,omitempty"`
@@ -49,20 +77,59 @@ type Position struct {
 	CubelessEquity float64 `json:"cubeless_equity,omitempty"`
 	CubefulEquity  float64 `json:"cubeful_equity,omitempty"`
 	EquityStdDev   float64 `json:"equity_std_dev,omitempty"`
+
+	// Dead and live cube equities and recube vig of advanced cube analysis (when present)
+	DeadCubeEquity float64 `json:"dead_cube_equity,omitempty"`
+	LiveCubeEquity float64 `json:"live_cube_equity,omitempty"`
+	RecubeVig      float64 `json:"recube_vig,omitempty"`
+
+	// Equity of each of the 21 rolls keyed like "6-5" or "3-3", higher die
+	// first (nil when the file has no per-roll table)
+	RollEquities map[string]float64 `json:"roll_equities,omitempty"`
+
+	// Recommended cube action as printed, e.g. "Double / Take" (when present)
+	Recommendation string `json:"recommendation,omitempty"`
+
+	// Analysis sections present: "checker", "cube" or "both" ("" when none)
+	Kind string `json:"kind,omitempty"`
+
+	// Player ("X" or "O") who won the game, set on Match.FinalPositions results
+	Winner string `json:"winner,omitempty"`
+
+	// Set when the file states the player on roll cannot move (e.g. dancing on the bar)
+	ForcedPass bool `json:"forced_pass,omitempty"`
+
+	// Free-text comment lines ("# ..." or "Comment: ..."), joined with newlines
+	Comment string `json:"comment,omitempty"`
+
+	// Non-fatal problems found while parsing (e.g. ignored malformed values)
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Rules holds the match rules in use
+type Rules struct {
+	Crawford   bool `json:"crawford"`
+	Jacoby     bool `json:"jacoby"`
+	Beavers    bool `json:"beavers"`
+	Raccoons   bool `json:"raccoons"`
+	AutoDouble bool `json:"auto_double"`
 }
 
 // Evaluation represents a move evaluation
 type Evaluation struct {
-	Rank   int     `json:"rank"`
-	Move   string  `json:"move"`
-	Equity float64 `json:"equity"`
-	Diff   float64 `json:"diff"`
-	Win    float64 `json:"win"`
-	WinG   float64 `json:"win_g"`
-	WinBG  float64 `json:"win_bg"`
-	LoseG  float64 `json:"lose_g"`
-	LoseBG float64 `json:"lose_bg"`
-	IsBest bool    `json:"is_best"`
+	Rank        int     `json:"rank"`         // Sequential position in the list, starting at 1
+	PrintedRank int     `json:"printed_rank"` // Rank as printed in the file (may repeat for tied moves)
+	Move        string  `json:"move"`
+	Equity      float64 `json:"equity"`
+	EquityCI    float64 `json:"equity_ci,omitempty"` // Rollout confidence interval (± value), 0 when not rolled out
+	Diff        float64 `json:"diff"`
+	Win         float64 `json:"win"`
+	WinG        float64 `json:"win_g"`
+	WinBG       float64 `json:"win_bg"`
+	LoseG       float64 `json:"lose_g"`
+	LoseBG      float64 `json:"lose_bg"`
+	IsBest      bool    `json:"is_best"`
+	Comment     string  `json:"comment,omitempty"` // Inline "# ..." comment after the move
 }
 
 // CubeDecision represents a cube decision analysis
@@ -82,8 +149,30 @@ type Match struct {
 	Compress bool   `json:"compress"`
 	UseSmile bool   `json:"useSmile"`
 
+	// Header fields other than the four above (e.g. "encoding", "app")
+	HeaderExtras map[string]interface{} `json:"header_extras,omitempty"`
+
 	// Match data will be populated from the JSON structure
 	Data map[string]interface{} `json:"data,omitempty"`
+
+	// Data with its original key order, set only with BGFOptions.PreserveKeyOrder
+	OrderedData *OrderedMap `json:"-"`
+
+	// Decoded content when the payload is not a JSON object (Data is nil then)
+	RawValue interface{} `json:"raw_value,omitempty"`
+
+	// Non-fatal problems encountered while decoding (e.g. skipped SMILE tokens)
+	DecodingWarnings []string `json:"decoding_warnings,omitempty"`
+
+	// Set when the data could only be partially decoded
+	Partial *PartialDecode `json:"partial,omitempty"`
+}
+
+// PartialDecode describes match data that could only be partially decoded.
+// Data then holds everything decoded before the failure.
+type PartialDecode struct {
+	Reason string `json:"reason"` // Why decoding stopped
+	Offset int    `json:"offset"` // Byte offset in the decompressed data past the last value decoded in full
 }
 
 // ParseError represents an error during parsing
@@ -91,6 +180,7 @@ type ParseError struct {
 	File    string
 	Line    int
 	Message string
+	Err     error // Underlying cause, if any (available via errors.Is/errors.As)
 }
 
 func (e *ParseError) Error() string {
@@ -99,3 +189,51 @@ func (e *ParseError) Error() string {
 	}
 	return fmt.Sprintf("%s: %s", e.File, e.Message)
 }
+
+// Unwrap returns the underlying cause of the parse error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Game represents a single game within a BGF match
+type Game struct {
+	ScoreGreen  int        `json:"score_green"` // Green's score at the start of the game
+	ScoreRed    int        `json:"score_red"`   // Red's score at the start of the game
+	WonPoints   int        `json:"won_points"`
+	Forfeit     bool       `json:"forfeit"`
+	Resignation bool       `json:"resignation"`
+	Moves       []GameMove `json:"moves"`
+}
+
+// GameMove represents a single action (checker play, double, take...) within a game
+type GameMove struct {
+	Type        string        `json:"type"`   // BGF move type, e.g. "amove"
+	Player      int           `json:"player"` // 1 for green, -1 for red
+	Dice        [2]int        `json:"dice"`   // Red and green die values (0 when not rolled)
+	Moves       []CheckerMove `json:"moves,omitempty"`
+	Equity      float64       `json:"equity"`       // Money game equity
+	MatchEquity float64       `json:"match_equity"` // Match winning probability
+
+	// Position analysis attached with Match.AttachAnalysis
+	Analysis *MoveAnalysis `json:"analysis,omitempty"`
+}
+
+// MoveAnalysis holds the evaluations of a position attached to a game move
+type MoveAnalysis struct {
+	Evaluations   []Evaluation   `json:"evaluations,omitempty"`
+	CubeDecisions []CubeDecision `json:"cube_decisions,omitempty"`
+}
+
+// StructuredMatch is the stable, documented projection of a BGF match
+// produced by Match.ToStructuredJSON
+type StructuredMatch struct {
+	Format      string `json:"format"`
+	Version     string `json:"version"`
+	MatchLength int    `json:"match_length"`
+	PlayerGreen string `json:"player_green"`
+	PlayerRed   string `json:"player_red"`
+	ScoreGreen  int    `json:"score_green"` // Final score
+	ScoreRed    int    `json:"score_red"`   // Final score
+	Date        string `json:"date"`
+	Games       []Game `json:"games"`
+}
@@ -5,7 +5,12 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/kevung/bgfparser/internal/smile"
 )
@@ -29,63 +34,366 @@ import (
 //	    w.Header().Set("Content-Type", "application/json")
 //	    json.NewEncoder(w).Encode(match)
 //	}
+//
+// If the compressed payload is cut short, the returned error wraps
+// ErrTruncatedBGF and the returned Match holds whatever could be decoded.
 func ParseBGFFromReader(reader io.Reader) (*Match, error) {
-	bufReader := bufio.NewReader(reader)
+	match, _, err := parseBGFReader(reader, BGFOptions{}, false)
+	return match, err
+}
+
+// ParseOptions holds the limits shared by the BGF and TXT parsers
+type ParseOptions struct {
+	// MaxBytes is the most input read before failing with an error wrapping
+	// ErrTooLarge (no limit if <= 0). Input is never buffered past this size,
+	// and the payload of a compressed BGF file is not decompressed past it.
+	MaxBytes int64
+}
+
+// limitReader returns r limited to maxBytes like io.LimitReader, except that
+// reading past the limit fails with ErrTooLarge instead of ending the input.
+// It returns r unchanged when maxBytes <= 0.
+func limitReader(r io.Reader, maxBytes int64) io.Reader {
+	if maxBytes <= 0 {
+		return r
+	}
+	return &limitedReader{r: io.LimitReader(r, maxBytes+1), remaining: maxBytes}
+}
+
+// limitedReader implements limitReader
+type limitedReader struct {
+	r         io.Reader // Limited to one byte past the limit, to detect overflow
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), ErrTooLarge
+	}
+	return n, err
+}
+
+// BGFOptions configures ParseBGFFromReaderWithOptions
+type BGFOptions struct {
+	ParseOptions
 
-	// Read first line (JSON header)
-	headerLine, err := bufReader.ReadBytes('\n')
+	// PreserveKeyOrder keeps the key order of the decoded objects in
+	// Match.OrderedData, which ToJSON then uses to serialize Data
+	PreserveKeyOrder bool
+}
+
+// ParseBGFFromReaderWithOptions parses a BGF file like ParseBGFFromReader, using the given options
+func ParseBGFFromReaderWithOptions(reader io.Reader, opts BGFOptions) (*Match, error) {
+	match, _, err := parseBGFReader(reader, opts, false)
+	return match, err
+}
+
+// ParseBGFFromReaderWithOffsets parses a BGF file like ParseBGFFromReader and
+// additionally returns, for each top-level key of the match data, the [start, end)
+// byte offsets of its value in the decompressed stream. This is intended for
+// debugging tools that need to map decoded fields back to raw bytes.
+func ParseBGFFromReaderWithOffsets(reader io.Reader) (*Match, map[string][2]int, error) {
+	return parseBGFReader(reader, BGFOptions{}, true)
+}
+
+// DecompressBGFPayload reads a BGF file from r and returns its header and
+// its payload decompressed but not decoded (SMILE or JSON, as stored), for
+// tools inspecting the raw data. The header has no Data. If the compressed
+// payload is cut short, the error wraps ErrTruncatedBGF and payload holds the
+// bytes decompressed so far.
+func DecompressBGFPayload(r io.Reader) (header *Match, payload []byte, err error) {
+	header, payload, truncErr, err := readBGFPayload(r, 0, new(bytes.Buffer), new(bytes.Buffer))
 	if err != nil {
-		return nil, &ParseError{Message: "failed to read header: " + err.Error()}
+		return nil, nil, err
+	}
+	return header, payload, truncErr
+}
+
+// maxPooledBGFBuffer is the largest buffer kept for reuse by bgfBufferPool, so
+// that one huge file does not pin its memory for the life of the process
+const maxPooledBGFBuffer = 16 << 20
+
+// bgfBufferPool holds the buffers staging the data of BGF files while they are
+// parsed, reused across parses to spare the garbage collector on busy servers
+var bgfBufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// getBGFBuffer returns an empty buffer from bgfBufferPool
+func getBGFBuffer() *bytes.Buffer {
+	return bgfBufferPool.Get().(*bytes.Buffer)
+}
+
+// putBGFBuffer empties a buffer and returns it to bgfBufferPool
+func putBGFBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBGFBuffer {
+		return
+	}
+	b.Reset()
+	bgfBufferPool.Put(b)
+}
+
+// gzipReaderPool holds gzip readers, whose decompression state is reused by Reset
+var gzipReaderPool sync.Pool
+
+// newGzipReader returns a gzip reader of r from gzipReaderPool
+func newGzipReader(r io.Reader) (*gzip.Reader, error) {
+	if gz, ok := gzipReaderPool.Get().(*gzip.Reader); ok {
+		if err := gz.Reset(r); err != nil {
+			gzipReaderPool.Put(gz)
+			return nil, err
+		}
+		return gz, nil
+	}
+	return gzip.NewReader(r)
+}
+
+// readBGFPayload reads the header of a BGF file and its payload, decompressed
+// when the header says so. The data after the header is read into raw and
+// decompressed into out, so payload shares the memory of one of them. After a
+// truncated gzip stream, payload holds the bytes decompressed so far and
+// truncErr wraps ErrTruncatedBGF.
+func readBGFPayload(reader io.Reader, maxBytes int64, raw, out *bytes.Buffer) (match *Match, payload []byte, truncErr error, err error) {
+	bufReader := bufio.NewReader(limitReader(reader, maxBytes))
+
+	// Read the JSON header line, tolerating a UTF-8 BOM and leading blank lines
+	headerLine, err := readBGFHeaderLine(bufReader)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	// Parse header
-	match := &Match{}
+	match = &Match{}
 	if err := json.Unmarshal(headerLine, match); err != nil {
-		return nil, &ParseError{Message: "failed to parse header: " + err.Error()}
+		return nil, nil, nil, &ParseError{Message: "failed to parse header: " + err.Error()}
 	}
 
 	// Read the rest of the data
-	restData, err := io.ReadAll(bufReader)
-	if err != nil {
-		return nil, &ParseError{Message: "failed to read data: " + err.Error()}
+	if _, err := raw.ReadFrom(bufReader); err != nil {
+		return nil, nil, nil, &ParseError{Message: "failed to read data: " + err.Error(), Err: err}
 	}
+	restData := raw.Bytes()
 
-	// Decompress if compressed
-	var jsonData []byte
+	// Decompress if compressed. A truncated gzip stream still yields the bytes
+	// decompressed so far, which parseBGFReader decodes into partial Data.
 	if match.Compress {
-		gzReader, err := gzip.NewReader(bytes.NewReader(restData))
+		var warnings []string
+		payload, warnings, err = decompressGzip(restData, out, maxBytes)
 		if err != nil {
-			return nil, &ParseError{Message: "failed to create gzip reader: " + err.Error()}
-		}
-		defer gzReader.Close()
-
-		jsonData, err = io.ReadAll(gzReader)
-		if err != nil {
-			return nil, &ParseError{Message: "failed to decompress: " + err.Error()}
+			if !errors.Is(err, ErrTruncatedBGF) {
+				return nil, nil, nil, err
+			}
+			truncErr = err
+			match.Partial = &PartialDecode{Reason: err.Error()}
 		}
+		match.DecodingWarnings = append(match.DecodingWarnings, warnings...)
 	} else {
-		jsonData = restData
+		// Uncompressed payloads, JSON or SMILE, follow the header line as is
+		payload = restData
+	}
+
+	return match, payload, truncErr, nil
+}
+
+// parseBGFReader implements ParseBGFFromReader, optionally recording value offsets
+func parseBGFReader(reader io.Reader, opts BGFOptions, recordOffsets bool) (*Match, map[string][2]int, error) {
+	// The payload is only staged in the buffers: decoded values never refer to it
+	raw, out := getBGFBuffer(), getBGFBuffer()
+	defer putBGFBuffer(raw)
+	defer putBGFBuffer(out)
+
+	match, jsonData, truncErr, err := readBGFPayload(reader, opts.MaxBytes, raw, out)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Some files flag SMILE encoding but hold plain JSON, decoded as such
+	useSmile := match.UseSmile
+	if useSmile && !bytes.HasPrefix(jsonData, smileMagic) && json.Valid(jsonData) {
+		useSmile = false
+		match.DecodingWarnings = append(match.DecodingWarnings, "payload flagged as SMILE has no SMILE header and was decoded as JSON")
 	}
 
 	// Handle SMILE encoding
-	if match.UseSmile {
+	var offsets map[string][2]int
+	if useSmile {
 		var data interface{}
-		if err := smile.Unmarshal(jsonData, &data); err != nil {
-			return nil, &ParseError{Message: "failed to decode SMILE: " + err.Error()}
+		dec := smile.Decoder{Recover: true, RecordOffsets: recordOffsets, PreserveOrder: opts.PreserveKeyOrder}
+		// After a gzip truncation, decode errors just mark where the data ends
+		if err := dec.Unmarshal(jsonData, &data); err != nil && truncErr == nil {
+			if !dec.Truncated {
+				return nil, nil, &ParseError{Message: "failed to decode SMILE: " + err.Error(), Err: err}
+			}
+			// Keep what was decoded before the data ended
+			match.Partial = &PartialDecode{Reason: err.Error()}
+			match.DecodingWarnings = append(match.DecodingWarnings, "SMILE data is truncated, match data is incomplete")
+		}
+		if match.Partial != nil {
+			match.Partial.Offset = dec.Decoded
+		}
+		offsets = dec.Offsets
+		for _, w := range dec.Warnings {
+			match.DecodingWarnings = append(match.DecodingWarnings, "skipped SMILE value at "+w.String())
+		}
+		if dec.SharedResets > 0 {
+			match.DecodingWarnings = append(match.DecodingWarnings,
+				fmt.Sprintf("SMILE shared string table reset %d time(s) after reaching 1024 entries", dec.SharedResets))
+		}
+
+		if opts.PreserveKeyOrder {
+			data = orderedFromSmile(data)
+			if ordered, ok := data.(*OrderedMap); ok {
+				match.OrderedData = ordered
+			}
+			data = unordered(data)
 		}
 
 		if dataMap, ok := data.(map[string]interface{}); ok {
 			match.Data = dataMap
 		} else {
-			match.Data = map[string]interface{}{"_data": data}
+			match.RawValue = data
 		}
 	} else {
 		if err := json.Unmarshal(jsonData, &match.Data); err != nil {
-			return nil, &ParseError{Message: "failed to parse JSON: " + err.Error()}
+			if truncErr != nil {
+				// Plain JSON cannot be decoded partially
+				return match, nil, truncErr
+			}
+			return nil, nil, &ParseError{Message: "failed to parse JSON: " + err.Error()}
+		}
+		if recordOffsets {
+			offsets = jsonValueOffsets(jsonData)
+		}
+		if opts.PreserveKeyOrder {
+			if ordered, err := decodeOrderedJSON(jsonData); err == nil {
+				match.OrderedData, _ = ordered.(*OrderedMap)
+			}
 		}
 	}
 
-	return match, nil
+	return match, offsets, truncErr
+}
+
+// jsonValueOffsets returns the [start, end) byte offsets of each top-level
+// object value in plain JSON data (nil if data is not a JSON object)
+func jsonValueOffsets(data []byte) map[string][2]int {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil
+	}
+
+	offsets := make(map[string][2]int)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return offsets
+		}
+		key, _ := tok.(string)
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return offsets
+		}
+		end := int(dec.InputOffset())
+		offsets[key] = [2]int{end - len(raw), end}
+	}
+
+	return offsets
+}
+
+// decompressGzip decompresses every gzip member in data. Bytes after the last
+// member that don't start a new member (e.g. a trailing newline) are ignored
+// and reported as a warning. The decompressed bytes are written to out, and
+// decompressing more than maxBytes fails with ErrTooLarge (no limit if <= 0).
+func decompressGzip(data []byte, out *bytes.Buffer, maxBytes int64) ([]byte, []string, error) {
+	src := bytes.NewReader(data)
+	gzReader, err := newGzipReader(src)
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, nil, truncatedError(len(data))
+		}
+		return nil, nil, &ParseError{Message: "failed to create gzip reader: " + err.Error(), Err: err}
+	}
+	defer gzipReaderPool.Put(gzReader)
+
+	// The limit counts the bytes of all members
+	limited := limitReader(gzReader, maxBytes)
+
+	var warnings []string
+	for {
+		// Read one member at a time so trailing bytes can be inspected
+		gzReader.Multistream(false)
+		if _, err := io.Copy(out, limited); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return out.Bytes(), warnings, truncatedError(len(data))
+			}
+			if err == ErrTooLarge {
+				return nil, nil, &ParseError{Message: "decompressed data exceeds the maximum size", Err: err}
+			}
+			return nil, nil, &ParseError{Message: "failed to decompress: " + err.Error(), Err: err}
+		}
+
+		rest := data[len(data)-src.Len():]
+		if len(rest) == 0 {
+			break
+		}
+		if len(rest) >= 2 && rest[0] == 0x1f && rest[1] == 0x8b {
+			if err := gzReader.Reset(src); err != nil {
+				return nil, nil, &ParseError{Message: "failed to read gzip member: " + err.Error(), Err: err}
+			}
+			continue
+		}
+
+		warnings = append(warnings, fmt.Sprintf("ignored %d trailing bytes after gzip data", len(rest)))
+		break
+	}
+
+	return out.Bytes(), warnings, nil
+}
+
+// truncatedError reports a gzip payload that ended after n compressed bytes
+func truncatedError(n int) error {
+	return &ParseError{
+		Message: fmt.Sprintf("BGF payload truncated after %d bytes; the file is incomplete, re-export or re-upload it", n),
+		Err:     ErrTruncatedBGF,
+	}
+}
+
+// headerSearchLines is the number of lines searched for the BGF JSON header
+const headerSearchLines = 5
+
+// smileMagic starts every SMILE encoded payload
+var smileMagic = []byte(":)\n")
+
+// utf8BOM is the byte order mark some tools prepend to text files
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// readBGFHeaderLine returns the first non-blank line, skipping a leading UTF-8 BOM.
+// The header line must end with a newline, as the payload follows it.
+func readBGFHeaderLine(bufReader *bufio.Reader) ([]byte, error) {
+	for i := 0; i < headerSearchLines; i++ {
+		line, err := bufReader.ReadBytes('\n')
+		if i == 0 {
+			line = bytes.TrimPrefix(line, utf8BOM)
+		}
+		if errors.Is(err, ErrTooLarge) {
+			return nil, &ParseError{Message: "failed to read header: " + err.Error(), Err: err}
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			if err == io.EOF {
+				return nil, &ParseError{Message: "BGF header line is not followed by a newline and payload"}
+			}
+			return line, nil
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &ParseError{Message: "failed to read header: " + err.Error(), Err: err}
+		}
+	}
+
+	return nil, &ParseError{Message: fmt.Sprintf("no BGF JSON header found in first %d lines", headerSearchLines)}
 }
 
 // ParseTXTFromReader parses a BGBlitz TXT position file from an io.Reader
@@ -97,23 +405,110 @@ func ParseBGFFromReader(reader io.Reader) (*Match, error) {
 //	data := []byte("... TXT content ...")
 //	pos, err := bgfparser.ParseTXTFromReader(bytes.NewReader(data))
 func ParseTXTFromReader(reader io.Reader) (*Position, error) {
+	return ParseTXTFromReaderWithOptions(reader, TXTOptions{})
+}
+
+// DefaultMaxLineLength is the longest TXT line accepted when TXTOptions.MaxLineLength is not set
+const DefaultMaxLineLength = 1 << 20
+
+// TXTOptions configures ParseTXTFromReaderWithOptions
+type TXTOptions struct {
+	ParseOptions
+
+	// MaxLineLength is the longest line accepted in bytes (DefaultMaxLineLength if <= 0).
+	// The line buffer grows as needed up to this size.
+	MaxLineLength int
+
+	// Strict makes the parser fail with a ParseError on a malformed number,
+	// which is otherwise silently read as zero, and clear implausible
+	// evaluation probabilities besides warning about them
+	Strict bool
+}
+
+// ParseTXTFromReaderWithOptions parses a BGBlitz TXT position file like
+// ParseTXTFromReader, using the given options. A line longer than
+// MaxLineLength fails with a ParseError wrapping bufio.ErrTooLong.
+func ParseTXTFromReaderWithOptions(reader io.Reader, opts TXTOptions) (*Position, error) {
+	return parseTXTReader(reader, opts, nil)
+}
+
+// parseTXTReader implements ParseTXTFromReaderWithOptions, scanning lines
+// with buf as the initial line buffer when it is large enough
+func parseTXTReader(reader io.Reader, opts TXTOptions, buf []byte) (*Position, error) {
+	reader = limitReader(reader, opts.MaxBytes)
+
 	pos := &Position{
 		OnBar:    make(map[string]int),
 		PipCount: make(map[string]int),
+		Off:      make(map[string]int),
+	}
+	hasOffLine := false
+	linePips := make(map[string]int)
+
+	maxLine := opts.MaxLineLength
+	if maxLine <= 0 {
+		maxLine = DefaultMaxLineLength
 	}
 
 	scanner := bufio.NewScanner(reader)
+	if size := min(maxLine, bufio.MaxScanTokenSize); cap(buf) < size {
+		buf = make([]byte, 0, size)
+	}
+	scanner.Buffer(buf[:0], maxLine)
+	scanner.Split(scanTXTLines)
 	lineNum := 0
 	boardLines := []string{}
 	inEvaluation := false
 	inCubeDecision := false
+	inRules := false
+	inRollTable := false
+	hasCheckerSection, hasCubeSection := false, false
 	evalRank := 0
+	var printedDiffs []bool // Whether each evaluation printed its diff
 	var lastEval *Evaluation
+	var nums numberReader
+	var xg xgState
 
 	for scanner.Scan() {
 		lineNum++
 		line := scanner.Text()
 
+		// Parse match metadata header lines
+		if parseMetadata(line, pos) {
+			continue
+		}
+
+		// Collect free-text comments
+		if parseCommentLine(line, pos) {
+			continue
+		}
+
+		// Parse the match rules footer
+		if parseRulesLine(line, &inRules, pos) {
+			continue
+		}
+
+		// Parse the lines laid out differently in XG position text
+		if parseXGLine(line, &xg, pos, &printedDiffs) {
+			continue
+		}
+
+		// Parse explicit bar and borne-off counts
+		if label, ok := parseBarOffLine(line, pos); ok {
+			hasOffLine = hasOffLine || label == "off"
+			continue
+		}
+
+		// Parse a dedicated pip count line
+		if parsePipLine(line, linePips) {
+			continue
+		}
+
+		// Parse the per-roll equity table
+		if parseRollEquityLine(line, &inRollTable, pos, &nums) {
+			continue
+		}
+
 		// Parse board lines
 		if parseBoardLine(line, &boardLines) {
 			continue
@@ -126,7 +521,9 @@ func ParseTXTFromReader(reader io.Reader) (*Position, error) {
 		parsePositionID(line, pos)
 
 		// Parse XGID
-		parseXGIDLine(line, pos)
+		if err := parseXGIDLine(line, pos); err != nil {
+			return nil, &ParseError{Line: lineNum, Message: err.Error()}
+		}
 
 		// Parse match score
 		parseMatchScore(line, pos)
@@ -139,38 +536,75 @@ func ParseTXTFromReader(reader io.Reader) (*Position, error) {
 			continue
 		}
 
+		// Parse the recommended cube action
+		parseCubeRecommendation(line, pos)
+
+		// Parse dead/live cube equities and recube vig before the "Videau"
+		// label can be taken for the French cube action header
+		if parseCubeLifeLine(line, pos, &nums) {
+			continue
+		}
+
+		// A player unable to move gets a marker instead of evaluations
+		if isForcedPassLine(line) {
+			pos.ForcedPass = true
+			continue
+		}
+
 		// Handle evaluation sections
 		if handleEvaluationSection(line, &inEvaluation, &inCubeDecision, &evalRank) {
+			hasCheckerSection = hasCheckerSection || inEvaluation
+			hasCubeSection = hasCubeSection || inCubeDecision
 			continue
 		}
 
 		// Parse evaluations
 		if inEvaluation && len(line) > 0 {
-			if eval := parseEvaluation(line, &evalRank); eval != nil {
+			if eval, hasDiff := parseEvaluation(line, &evalRank, &nums); eval != nil {
 				pos.Evaluations = append(pos.Evaluations, *eval)
+				printedDiffs = append(printedDiffs, hasDiff)
 				lastEval = &pos.Evaluations[len(pos.Evaluations)-1]
 			} else if lastEval != nil {
+				// A long move list may wrap onto the next line
+				if rest, ok := parseMoveContinuation(line); ok {
+					lastEval.Move = strings.TrimSpace(lastEval.Move) + " " + rest
+					continue
+				}
 				// Try to parse probability line for the last evaluation
-				if parseProbabilityLine(line, lastEval) {
+				if parseProbabilityLine(line, lastEval, &nums) {
 					lastEval = nil // Reset after parsing probabilities
 				}
 			}
 		}
 
 		// Try to parse equity information (appears before cube decision section)
-		parseEquityInfo(line, pos)
+		parseEquityInfo(line, pos, &nums)
 
 		// Parse cube decisions
 		if inCubeDecision {
 			// Parse cube decision line
-			if decision := parseCubeDecision(line); decision != nil {
+			if decision := parseCubeDecision(line, &nums); decision != nil {
 				pos.CubeDecisions = append(pos.CubeDecisions, *decision)
 			}
 		}
+
+		// A malformed number was read as zero, which only strict mode reports
+		if nums.err != nil {
+			if opts.Strict {
+				message := fmt.Sprintf("malformed number %q", nums.err.Num)
+				return nil, &ParseError{Line: lineNum, Message: message, Err: nums.err}
+			}
+			nums.err = nil
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, &ParseError{Message: err.Error()}
+		// The failing line is the one after the last successfully scanned line
+		message := err.Error()
+		if err == bufio.ErrTooLong {
+			message = fmt.Sprintf("line exceeds the maximum length of %d bytes", maxLine)
+		}
+		return nil, &ParseError{Line: lineNum + 1, Message: message, Err: err}
 	}
 
 	// Parse the board from collected lines
@@ -178,11 +612,102 @@ func ParseTXTFromReader(reader io.Reader) (*Position, error) {
 		parseBoard(pos, boardLines)
 	}
 
+	// Some exports draw the dice on the board instead of the "to move" line
+	if pos.Dice == (Dice{}) {
+		parseBoardDice(pos, boardLines)
+	}
+
+	// A dedicated pip count line fills in counts missing from the player header
+	mergePipLine(pos, linePips)
+
+	// Without an explicit "Off" line, derive borne-off checkers from the XGID board
+	if !hasOffLine && pos.XGID != "" {
+		for _, player := range []string{"X", "O"} {
+			pos.Off[player] = pos.BorneOff(player)
+		}
+	}
+
+	// Without an XGID, take the match state from the Match-ID
+	if pos.XGID == "" && pos.MatchID != "" {
+		applyMatchID(pos)
+	}
+
+	// A Position-ID disagreeing with the XGID signals a corrupt file
+	if pos.XGID != "" && pos.PositionID != "" && pos.OnRoll != "" {
+		checkPositionID(pos)
+	}
+
+	updateCrawfordState(pos)
+	fillEvaluationDiffs(pos.Evaluations, printedDiffs)
+	markBestEvaluation(pos.Evaluations)
+	checkProbabilities(pos, opts.Strict)
+	markRecommendedCubeAction(pos)
+	pos.Kind = analysisKind(hasCheckerSection || xg.checker, hasCubeSection || xg.cube)
+
 	return pos, nil
 }
 
-// ToJSON serializes the Match to JSON
+// BGFToJSON parses a BGF file from r and writes its decoded match data to w
+// as JSON, without the BGF header fields. Internal "_"-prefixed keys are
+// omitted at every level. With indent set the output is indented with two spaces.
+func BGFToJSON(r io.Reader, w io.Writer, indent bool) error {
+	match, err := ParseBGFFromReader(r)
+	if err != nil {
+		return err
+	}
+
+	var data interface{} = match.RawValue
+	if match.Data != nil {
+		data = publicValue(match.Data)
+	}
+
+	enc := json.NewEncoder(w)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(data)
+}
+
+// publicValue returns a copy of a decoded value without "_"-prefixed object keys
+func publicValue(v interface{}) interface{} {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(node))
+		for k, child := range node {
+			if strings.HasPrefix(k, "_") {
+				continue
+			}
+			out[k] = publicValue(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(node))
+		for i, child := range node {
+			out[i] = publicValue(child)
+		}
+		return out
+	}
+	return v
+}
+
+// DisassembleSMILE writes a token listing of SMILE data (starting with the
+// ":)\n" header, as found in a decompressed BGF payload) to w: one line per
+// token with its offset, raw bytes, type and decoded value. It is meant for
+// inspecting files the decoder rejects or misreads.
+func DisassembleSMILE(w io.Writer, data []byte) error {
+	return smile.Disassemble(w, data)
+}
+
+// ToJSON serializes the Match to JSON. When the match was decoded with
+// BGFOptions.PreserveKeyOrder, Data is written in the key order of the file.
 func (m *Match) ToJSON() ([]byte, error) {
+	if m.OrderedData != nil {
+		type plainMatch Match
+		return json.MarshalIndent(struct {
+			*plainMatch
+			Data *OrderedMap `json:"data,omitempty"`
+		}{(*plainMatch)(m), m.OrderedData}, "", "  ")
+	}
 	return json.MarshalIndent(m, "", "  ")
 }
 
@@ -190,3 +715,67 @@ func (m *Match) ToJSON() ([]byte, error) {
 func (p *Position) ToJSON() ([]byte, error) {
 	return json.MarshalIndent(p, "", "  ")
 }
+
+// structuredSources maps the StructuredMatch fields read from Data to their
+// data key, in the default output order
+var structuredSources = []struct{ field, key string }{
+	{"match_length", "matchlen"},
+	{"player_green", "nameGreen"},
+	{"player_red", "nameRed"},
+	{"score_green", "finalGreen"},
+	{"score_red", "finalRed"},
+	{"date", "date"},
+	{"games", "games"},
+}
+
+// ToStructuredJSON serializes the Match into the stable StructuredMatch shape
+// (players, final scores, games and moves) rather than the raw decoded Data.
+// Internal "_"-prefixed keys of Data are never part of the output. When key
+// order is preserved (OrderedData is set), the match fields follow the order
+// of their data keys in the file, after the header fields; fields whose key
+// is absent come last.
+func (m *Match) ToStructuredJSON() ([]byte, error) {
+	structured := StructuredMatch{
+		Format:      m.Format,
+		Version:     m.Version,
+		MatchLength: intValue(m.Data["matchlen"]),
+		PlayerGreen: stringValue(m.Data["nameGreen"]),
+		PlayerRed:   stringValue(m.Data["nameRed"]),
+		ScoreGreen:  intValue(m.Data["finalGreen"]),
+		ScoreRed:    intValue(m.Data["finalRed"]),
+		Date:        stringValue(m.Data["date"]),
+		Games:       m.Games(),
+	}
+	if m.OrderedData == nil {
+		return json.MarshalIndent(structured, "", "  ")
+	}
+
+	data, err := json.Marshal(structured)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	position := make(map[string]int, len(m.OrderedData.Keys))
+	for i, key := range m.OrderedData.Keys {
+		position[key] = i
+	}
+	sources := append(structuredSources[:0:0], structuredSources...)
+	sort.SliceStable(sources, func(i, j int) bool {
+		pi, iok := position[sources[i].key]
+		pj, jok := position[sources[j].key]
+		return iok && (!jok || pi < pj)
+	})
+
+	ordered := &OrderedMap{Keys: []string{"format", "version"}, Values: make(map[string]interface{}, len(values))}
+	for _, source := range sources {
+		ordered.Keys = append(ordered.Keys, source.field)
+	}
+	for key, value := range values {
+		ordered.Values[key] = value
+	}
+	return json.MarshalIndent(ordered, "", "  ")
+}
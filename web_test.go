@@ -2,8 +2,17 @@ package bgfparser
 
 import (
 	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/kevung/bgfparser/internal/smile"
 )
 
 func TestParseBGFFromReader(t *testing.T) {
@@ -136,3 +145,898 @@ func TestPositionToJSON(t *testing.T) {
 		t.Error("Expected JSON to contain PlayerB")
 	}
 }
+
+func TestMatchToStructuredJSON(t *testing.T) {
+	header := `{"format":"BGF","version":"1.0","compress":false,"useSmile":false}` + "\n"
+	data := `{"matchlen":3,"nameGreen":"Alice","nameRed":"Bob","finalGreen":3,"finalRed":1,` +
+		`"date":"Nov 2, 2025","_decodeError":"boom","games":[{"scoreGreen":0,"scoreRed":0,` +
+		`"wonPoints":2,"wasForfeit":false,"wasResignation":true,"moves":[{"type":"amove",` +
+		`"player":1,"red":6,"green":4,"from":[18,12,-1,-1],"to":[12,8,-1,-1],` +
+		`"equity":{"equity":-1.047,"matchEquity":0.247,"_internal":1}}]}]}`
+
+	match, err := ParseBGFFromReader(strings.NewReader(header + data))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+
+	jsonData, err := match.ToStructuredJSON()
+	if err != nil {
+		t.Fatalf("ToStructuredJSON failed: %v", err)
+	}
+
+	if bytes.Contains(jsonData, []byte(`"_`)) {
+		t.Errorf("Structured JSON contains internal keys: %s", jsonData)
+	}
+
+	// Minimal inline schema: required keys and their JSON types
+	matchSchema := map[string]string{
+		"format": "string", "version": "string", "match_length": "number",
+		"player_green": "string", "player_red": "string",
+		"score_green": "number", "score_red": "number", "date": "string", "games": "array",
+	}
+	gameSchema := map[string]string{
+		"score_green": "number", "score_red": "number", "won_points": "number",
+		"forfeit": "bool", "resignation": "bool", "moves": "array",
+	}
+	moveSchema := map[string]string{
+		"type": "string", "player": "number", "dice": "array", "moves": "array",
+		"equity": "number", "match_equity": "number",
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(jsonData, &decoded); err != nil {
+		t.Fatalf("Structured JSON is invalid: %v", err)
+	}
+	validateSchema(t, "match", decoded, matchSchema)
+
+	games := decoded["games"].([]interface{})
+	if len(games) != 1 {
+		t.Fatalf("Expected 1 game, got %d", len(games))
+	}
+	game := games[0].(map[string]interface{})
+	validateSchema(t, "game", game, gameSchema)
+
+	moves := game["moves"].([]interface{})
+	if len(moves) != 1 {
+		t.Fatalf("Expected 1 move, got %d", len(moves))
+	}
+	validateSchema(t, "move", moves[0].(map[string]interface{}), moveSchema)
+
+	if decoded["player_green"] != "Alice" || decoded["match_length"] != float64(3) {
+		t.Errorf("Unexpected match fields: %v", decoded)
+	}
+}
+
+func TestMatchToStructuredJSON_PreserveKeyOrder(t *testing.T) {
+	header := `{"format":"BGF","version":"1.0","compress":false,"useSmile":false}` + "\n"
+	data := `{"games":[{"wonPoints":1,"moves":[]}],"nameRed":"Bob","matchlen":3,"nameGreen":"Alice"}`
+
+	match, err := ParseBGFFromReaderWithOptions(strings.NewReader(header+data), BGFOptions{PreserveKeyOrder: true})
+	if err != nil {
+		t.Fatalf("ParseBGFFromReaderWithOptions failed: %v", err)
+	}
+	jsonData, err := match.ToStructuredJSON()
+	if err != nil {
+		t.Fatalf("ToStructuredJSON failed: %v", err)
+	}
+
+	// Fields found in the data follow its key order, the others keep theirs
+	want := []string{"format", "version", "games", "player_red", "match_length", "player_green", "score_green", "score_red", "date"}
+	ordered, err := decodeOrderedJSON(jsonData)
+	if err != nil {
+		t.Fatalf("Structured JSON is invalid: %v", err)
+	}
+	if keys := ordered.(*OrderedMap).Keys; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Keys = %v, want %v", keys, want)
+	}
+
+	// The content matches the unordered output
+	plain, err := ParseBGFFromReader(strings.NewReader(header + data))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	plainJSON, err := plain.ToStructuredJSON()
+	if err != nil {
+		t.Fatalf("ToStructuredJSON failed: %v", err)
+	}
+	var got, expected map[string]interface{}
+	if json.Unmarshal(jsonData, &got) != nil || json.Unmarshal(plainJSON, &expected) != nil || !reflect.DeepEqual(got, expected) {
+		t.Errorf("Ordered output\n%s\ndiffers from\n%s", jsonData, plainJSON)
+	}
+}
+
+// validateSchema checks that obj has exactly the keys of schema with matching JSON types
+func validateSchema(t *testing.T, name string, obj map[string]interface{}, schema map[string]string) {
+	t.Helper()
+	for key := range obj {
+		if _, ok := schema[key]; !ok {
+			t.Errorf("%s: unexpected key %q", name, key)
+		}
+	}
+	for key, typ := range schema {
+		value, ok := obj[key]
+		if !ok {
+			t.Errorf("%s: missing key %q", name, key)
+			continue
+		}
+		var got string
+		switch value.(type) {
+		case string:
+			got = "string"
+		case float64:
+			got = "number"
+		case bool:
+			got = "bool"
+		case []interface{}:
+			got = "array"
+		case map[string]interface{}:
+			got = "object"
+		}
+		if got != typ {
+			t.Errorf("%s: key %q has type %s, want %s", name, key, got, typ)
+		}
+	}
+}
+
+// smileBGFHeader is the header line of an uncompressed SMILE BGF file
+const smileBGFHeader = `{"format":"BGF","version":"1.0","compress":false,"useSmile":true}` + "\n"
+
+// smileBGF returns an uncompressed SMILE BGF file whose payload is a SMILE
+// header with the given flags (0x01 shared keys, 0x02 shared values, 0x04
+// raw binary) followed by body
+func smileBGF(flags byte, body []byte) io.Reader {
+	data := append([]byte(smileBGFHeader+":)\n"), flags)
+	return bytes.NewReader(append(data, body...))
+}
+
+func TestParseBGFFromReader_TruncatedSMILEOffset(t *testing.T) {
+	// {"a": 1, "b": 2, "c" with the data ending after the last key
+	body := []byte{0xfa, 0x80, 'a', 0xc2, 0x80, 'b', 0xc4, 0x80, 'c'}
+
+	match, err := ParseBGFFromReader(smileBGF(0x00, body))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	if match.Partial == nil {
+		t.Fatal("Expected a partial match")
+	}
+	if match.Data["a"] != int64(1) || match.Data["b"] != int64(2) {
+		t.Errorf("Data = %v, want a and b", match.Data)
+	}
+
+	// The 4-byte SMILE header and the a and b members were decoded in full
+	if match.Partial.Offset != 11 {
+		t.Errorf("Partial.Offset = %d, want 11", match.Partial.Offset)
+	}
+}
+
+func TestParseBGFFromReader_SMILERecovery(t *testing.T) {
+	// {"a": 1, "inner": {"x": <reserved token 0x27>}, "c": 3}
+	body := []byte{0xfa, 0x80, 'a', 0xc2}
+	body = append(body, 0x84, 'i', 'n', 'n', 'e', 'r', 0xfa, 0x80, 'x', 0x27, 0xfb)
+	body = append(body, 0x80, 'c', 0xc6, 0xfb)
+
+	match, err := ParseBGFFromReader(smileBGF(0x00, body))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+
+	if match.Data["a"] != int64(1) {
+		t.Errorf("Data[a] = %v, want 1", match.Data["a"])
+	}
+	if match.Data["c"] != int64(3) {
+		t.Errorf("Data[c] = %v, want 3", match.Data["c"])
+	}
+	if _, ok := match.Data["inner"].(map[string]interface{}); !ok {
+		t.Errorf("Data[inner] = %v, want object", match.Data["inner"])
+	}
+
+	if len(match.DecodingWarnings) != 1 {
+		t.Fatalf("Expected 1 decoding warning, got %v", match.DecodingWarnings)
+	}
+	if !strings.Contains(match.DecodingWarnings[0], "offset 17") {
+		t.Errorf("Warning %q does not report the token offset", match.DecodingWarnings[0])
+	}
+}
+
+func TestParseBGFFromReader_SMILEBinary(t *testing.T) {
+	blob := []byte{0x00, 0xff, 0xfa, 0x7f}
+
+	// {"raw": <raw binary blob>, "safe": <7-bit binary de ad be>}
+	body := []byte{0xfa, 0x82, 'r', 'a', 'w', 0xfd, 0x84}
+	body = append(body, blob...)
+	body = append(body, 0x83, 's', 'a', 'f', 'e', 0xe8, 0x83, 0x6f, 0x2b, 0x37, 0x06, 0xfb)
+
+	// Header flag 0x04 enables raw binary
+	match, err := ParseBGFFromReader(smileBGF(0x04, body))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	if got, _ := match.Data["raw"].([]byte); !bytes.Equal(got, blob) {
+		t.Errorf("Data[raw] = %v, want %v", match.Data["raw"], blob)
+	}
+	if got, _ := match.Data["safe"].([]byte); !bytes.Equal(got, []byte{0xde, 0xad, 0xbe}) {
+		t.Errorf("Data[safe] = %x, want deadbe", match.Data["safe"])
+	}
+
+	// Without the flag a raw binary token is invalid and skipped
+	match, err = ParseBGFFromReader(smileBGF(0x00, body))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	if _, ok := match.Data["raw"]; ok {
+		t.Errorf("Data[raw] = %v, want it skipped", match.Data["raw"])
+	}
+	if len(match.DecodingWarnings) == 0 {
+		t.Error("Expected a decoding warning for the raw binary token")
+	}
+}
+
+func TestParseBGFFromReader_SMILEBinaryLength(t *testing.T) {
+	// {"a": <binary of a length the input cannot hold>, "b": 1}: a length
+	// overflowing to a negative value, and one merely past the input's end
+	huge := append(bytes.Repeat([]byte{0x7f}, 9), 0xbf)
+	long := []byte{0x40, 0x80}
+	for _, tok := range []byte{0xfd, 0xe8} {
+		for _, length := range [][]byte{huge, long} {
+			body := []byte{0xfa, 0x80, 'a', tok}
+			body = append(body, length...)
+			body = append(body, 0x80, 'b', 0xc2, 0xfb)
+
+			match, err := ParseBGFFromReader(smileBGF(0x04, body))
+			if err != nil {
+				t.Fatalf("Token %x, length % x: ParseBGFFromReader failed: %v", tok, length, err)
+			}
+			if _, ok := match.Data["a"]; ok {
+				t.Errorf("Token %x, length % x: Data[a] = %v, want it skipped", tok, length, match.Data["a"])
+			}
+			if len(match.DecodingWarnings) == 0 || !strings.Contains(match.DecodingWarnings[0], "invalid binary length") {
+				t.Errorf("Token %x, length % x: DecodingWarnings = %v, want an invalid length", tok, length, match.DecodingWarnings)
+			}
+		}
+	}
+}
+
+// smileSafeBinary encodes data as a SMILE 7-bit safe binary value: the
+// token, the raw length, then the bits of data in 7-bit groups, the last
+// group right-aligned
+func smileSafeBinary(data []byte) []byte {
+	n := len(data)
+	length := []byte{0x80 | byte(n&0x3f)}
+	for n >>= 6; n > 0; n >>= 7 {
+		length = append([]byte{byte(n & 0x7f)}, length...)
+	}
+	out := append([]byte{0xe8}, length...)
+
+	var acc, bits uint
+	for _, b := range data {
+		acc = acc<<8 | uint(b)
+		bits += 8
+		for bits >= 7 {
+			bits -= 7
+			out = append(out, byte(acc>>bits)&0x7f)
+		}
+	}
+	if bits > 0 {
+		out = append(out, byte(acc)&(1<<bits-1))
+	}
+	return out
+}
+
+func TestParseBGFFromReader_SMILEBinaryInArray(t *testing.T) {
+	blobs := [][]byte{
+		{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}, // One full 7-byte group
+		[]byte("board state 0123456789"),           // Several groups and a partial one
+		{0xff},
+		{},
+	}
+
+	// {"blobs": [<blob 0>, 5, <blob 1>, <blob 2>, <blob 3>]}
+	data := []byte{0xfa, 0x84, 'b', 'l', 'o', 'b', 's', 0xf8}
+	data = append(data, smileSafeBinary(blobs[0])...)
+	data = append(data, 0xca)
+	for _, blob := range blobs[1:] {
+		data = append(data, smileSafeBinary(blob)...)
+	}
+	data = append(data, 0xf9, 0xfb)
+
+	match, err := ParseBGFFromReader(smileBGF(0x00, data))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	if len(match.DecodingWarnings) != 0 {
+		t.Errorf("Unexpected decoding warnings: %v", match.DecodingWarnings)
+	}
+
+	values, ok := match.Data["blobs"].([]interface{})
+	if !ok || len(values) != 5 {
+		t.Fatalf("Data[blobs] = %v, want 5 values", match.Data["blobs"])
+	}
+	if values[1] != int64(5) {
+		t.Errorf("Data[blobs][1] = %v, want 5", values[1])
+	}
+	for i, j := range []int{0, 2, 3, 4} {
+		got, ok := values[j].([]byte)
+		if !ok || !bytes.Equal(got, blobs[i]) {
+			t.Errorf("Data[blobs][%d] = %#v, want %x", j, values[j], blobs[i])
+		}
+	}
+}
+
+func TestParseBGFFromReader_SMILESharedTableReset(t *testing.T) {
+	// {"a": ["s0000", ..., "s1029", <refs>]} with shared values enabled
+	const count = 1030
+	data := []byte{0xfa, 0x80, 'a', 0xf8}
+	for i := 0; i < count; i++ {
+		data = append(data, 0x44)
+		data = append(data, fmt.Sprintf("s%04d", i)...)
+	}
+	// Short references to entries 0 and 5, and a long reference to entry 3,
+	// all made after the table was cleared at the 1025th string
+	data = append(data, 0x01, 0x06, 0xec, 0x03)
+	data = append(data, 0xf9, 0xfb)
+
+	match, err := ParseBGFFromReader(smileBGF(0x03, data))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+
+	values, ok := match.Data["a"].([]interface{})
+	if !ok || len(values) != count+3 {
+		t.Fatalf("Data[a] has %d values, want %d", len(values), count+3)
+	}
+	if want := []interface{}{"s1024", "s1029", "s1027"}; !reflect.DeepEqual(values[count:], want) {
+		t.Errorf("References resolved to %v, want %v", values[count:], want)
+	}
+
+	if len(match.DecodingWarnings) != 1 || !strings.Contains(match.DecodingWarnings[0], "reset 1 time(s)") {
+		t.Errorf("DecodingWarnings = %v, want one table reset", match.DecodingWarnings)
+	}
+
+	// Referencing an entry past the new table's end is skipped in recovery mode
+	bad := append(append([]byte(nil), data[:len(data)-6]...), 0x07, 0xf9, 0xfb)
+	match, err = ParseBGFFromReader(smileBGF(0x03, bad))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	if len(match.DecodingWarnings) == 0 || !strings.Contains(match.DecodingWarnings[0], "invalid shared string reference 6") {
+		t.Errorf("DecodingWarnings = %v, want the invalid reference skipped", match.DecodingWarnings)
+	}
+}
+
+func TestParseBGFFromReader_SMILELongSharedValues(t *testing.T) {
+	// {"a": ["s00", ..., "s39", <long string>, <refs>]} with shared values
+	// enabled. Only short strings are shared, so the long string takes no
+	// table entry and entries past the 31 reachable by short references
+	// need a long reference.
+	const count = 40
+	long := strings.Repeat("a long string value ", 4)
+	data := []byte{0xfa, 0x80, 'a', 0xf8}
+	for i := 0; i < count; i++ {
+		data = append(data, 0x42)
+		data = append(data, fmt.Sprintf("s%02d", i)...)
+	}
+	data = append(data, 0xe0)
+	data = append(data, long...)
+	data = append(data, 0xfc)
+	data = append(data, 0xec, 0x23, 0xec, 0x27, 0x1f)
+	data = append(data, 0xf9, 0xfb)
+
+	match, err := ParseBGFFromReader(smileBGF(0x03, data))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	values, ok := match.Data["a"].([]interface{})
+	if !ok || len(values) != count+4 {
+		t.Fatalf("Data[a] has %d values, want %d", len(values), count+4)
+	}
+	if want := []interface{}{long, "s35", "s39", "s30"}; !reflect.DeepEqual(values[count:], want) {
+		t.Errorf("Values = %q, want %q", values[count:], want)
+	}
+
+	// A long reference to the slot the long string would have taken is
+	// skipped in recovery mode like an invalid short reference
+	bad := append(append([]byte(nil), data[:len(data)-7]...), 0xec, 0x28, 0xf9, 0xfb)
+	match, err = ParseBGFFromReader(smileBGF(0x03, bad))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	if len(match.DecodingWarnings) == 0 || !strings.Contains(match.DecodingWarnings[0], "invalid shared string reference 40") {
+		t.Errorf("DecodingWarnings = %v, want the invalid reference skipped", match.DecodingWarnings)
+	}
+}
+
+func TestParseBGFFromReader_SMILEHeaderErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{"Unsupported version", ":)\n\x10\xfa\xfb", ErrUnsupportedSmileVersion},
+		{"Invalid header", "not SMILE", ErrInvalidSmileHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBGFFromReader(strings.NewReader(smileBGFHeader + tt.data))
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBGFFromReader_HeaderPrefix(t *testing.T) {
+	body := `{"format":"BGF","version":"1.0","compress":false,"useSmile":false}` + "\n" + `{"test":"data"}`
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"BOM", "\xef\xbb\xbf" + body},
+		{"Blank lines", "\n  \r\n" + body},
+		{"BOM and blank line", "\xef\xbb\xbf\n" + body},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, err := ParseBGFFromReader(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatalf("ParseBGFFromReader failed: %v", err)
+			}
+			if match.Format != "BGF" || match.Data["test"] != "data" {
+				t.Errorf("Unexpected match: format=%q data=%v", match.Format, match.Data)
+			}
+
+			// Format detection must agree with the parser
+			result, err := Parse(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if _, ok := result.(*Match); !ok {
+				t.Errorf("Parse returned %T, want *Match", result)
+			}
+		})
+	}
+}
+
+func TestParseBGFFromReader_HeaderWithoutNewline(t *testing.T) {
+	tests := []string{
+		`{"format":"BGF","version":"1.0","compress":true,"useSmile":true}`,
+		`{"format":"BGF","version":"1.0","compress":false,"useSmile":false}`,
+		"\xef\xbb\xbf\n" + `{"format":"BGF","version":"1.0","compress":false,"useSmile":true}`,
+	}
+
+	for _, content := range tests {
+		match, err := ParseBGFFromReader(strings.NewReader(content))
+		if err == nil {
+			t.Errorf("Expected an error for %q, got match %+v", content, match)
+			continue
+		}
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("Expected a *ParseError, got %T", err)
+		}
+	}
+}
+
+func TestParseBGFFromReader_HeaderExtras(t *testing.T) {
+	content := `{"format":"BGF","version":"1.0","compress":false,"useSmile":false,"encoding":"UTF-8","app":{"name":"BGBlitz","build":6}}` +
+		"\n" + `{"test":"data"}`
+
+	match, err := ParseBGFFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	if match.Format != "BGF" || match.Version != "1.0" || match.Data["test"] != "data" {
+		t.Errorf("Unexpected match: format=%q version=%q data=%v", match.Format, match.Version, match.Data)
+	}
+
+	want := map[string]interface{}{
+		"encoding": "UTF-8",
+		"app":      map[string]interface{}{"name": "BGBlitz", "build": float64(6)},
+	}
+	if !reflect.DeepEqual(match.HeaderExtras, want) {
+		t.Errorf("HeaderExtras = %v, want %v", match.HeaderExtras, want)
+	}
+
+	// A header with only the known fields has no extras
+	match, err = ParseBGFFromReader(strings.NewReader(`{"format":"BGF","version":"1.0","compress":false,"useSmile":false}` + "\n" + `{}`))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	if match.HeaderExtras != nil {
+		t.Errorf("HeaderExtras = %v, want nil", match.HeaderExtras)
+	}
+}
+
+func TestParseBGFFromReader_HeaderKeyCase(t *testing.T) {
+	compressed, err := os.ReadFile("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	payload := compressed[bytes.IndexByte(compressed, '\n')+1:]
+
+	header := `{"Format":"BGF","VERSION":"1.0","Compress":true,"UseSMILE":true}` + "\n"
+	match, err := ParseBGFFromReader(bytes.NewReader(append([]byte(header), payload...)))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	if match.Format != "BGF" || match.Version != "1.0" || !match.Compress || !match.UseSmile {
+		t.Errorf("Header = %q %q %v %v, want BGF 1.0 true true", match.Format, match.Version, match.Compress, match.UseSmile)
+	}
+	if match.HeaderExtras != nil {
+		t.Errorf("HeaderExtras = %v, want nil", match.HeaderExtras)
+	}
+	if len(match.Games()) != 6 {
+		t.Errorf("Got %d games, want 6", len(match.Games()))
+	}
+
+	// The exact key wins over another casing, wherever it appears
+	for _, header := range []string{
+		`{"format":"BGF","compress":true,"Compress":false}`,
+		`{"format":"BGF","Compress":false,"compress":true}`,
+	} {
+		var m Match
+		if err := json.Unmarshal([]byte(header), &m); err != nil {
+			t.Fatalf("Unmarshal failed: %v", err)
+		}
+		if !m.Compress {
+			t.Errorf("%s: Compress = false, want the value of \"compress\"", header)
+		}
+	}
+}
+
+func TestParseBGFFromReader_NoHeader(t *testing.T) {
+	_, err := ParseBGFFromReader(strings.NewReader("\n\n\n\n\n\n" + `{"format":"BGF"}` + "\n"))
+	if err == nil {
+		t.Fatal("Expected error when no header is found")
+	}
+	if !strings.Contains(err.Error(), "no BGF JSON header found in first 5 lines") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestParseBGFFromReaderWithOffsets(t *testing.T) {
+	// {"a": 1, "inner": {"x": 2}, "c": "hi"}
+	body := []byte{0xfa, 0x80, 'a', 0xc2}
+	body = append(body, 0x84, 'i', 'n', 'n', 'e', 'r', 0xfa, 0x80, 'x', 0xc4, 0xfb)
+	body = append(body, 0x80, 'c', 0x41, 'h', 'i', 0xfb)
+
+	match, offsets, err := ParseBGFFromReaderWithOffsets(smileBGF(0x00, body))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReaderWithOffsets failed: %v", err)
+	}
+	if match.Data["c"] != "hi" {
+		t.Errorf("Data[c] = %v, want hi", match.Data["c"])
+	}
+
+	want := map[string][]byte{
+		"a":     {0xc2},
+		"inner": {0xfa, 0x80, 'x', 0xc4, 0xfb},
+		"c":     {0x41, 'h', 'i'},
+	}
+	if len(offsets) != len(want) {
+		t.Fatalf("Expected %d offsets, got %v", len(want), offsets)
+	}
+	for key, raw := range want {
+		off, ok := offsets[key]
+		if !ok {
+			t.Errorf("No offset recorded for %q", key)
+			continue
+		}
+		// Offsets count from the start of the 4-byte SMILE header
+		if got := body[off[0]-4 : off[1]-4]; !bytes.Equal(got, raw) {
+			t.Errorf("Offsets for %q = %v point at % x, want % x", key, off, got, raw)
+		}
+	}
+}
+
+func TestParseBGFFromReaderWithOffsets_TopLevelArray(t *testing.T) {
+	// [{"a": 1}, {"b": 2}]: keys of nested objects are not top-level keys
+	body := []byte{0xf8, 0xfa, 0x80, 'a', 0xc2, 0xfb, 0xfa, 0x80, 'b', 0xc4, 0xfb, 0xf9}
+
+	_, offsets, _ := ParseBGFFromReaderWithOffsets(smileBGF(0x00, body))
+	if len(offsets) != 0 {
+		t.Errorf("Expected no offsets for a top-level array, got %v", offsets)
+	}
+}
+
+func TestParseBGFFromReaderWithOffsets_JSON(t *testing.T) {
+	header := `{"format":"BGF","version":"1.0","compress":false,"useSmile":false}` + "\n"
+	data := `{"nameGreen": "Alice", "games": [1, 2]}`
+
+	_, offsets, err := ParseBGFFromReaderWithOffsets(strings.NewReader(header + data))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReaderWithOffsets failed: %v", err)
+	}
+
+	want := map[string]string{"nameGreen": `"Alice"`, "games": `[1, 2]`}
+	for key, raw := range want {
+		off := offsets[key]
+		if got := data[off[0]:off[1]]; got != raw {
+			t.Errorf("Offsets for %q point at %q, want %q", key, got, raw)
+		}
+	}
+}
+
+func TestParseBGFFromReaderWithOptions_PreserveKeyOrder(t *testing.T) {
+	// {"nameRed": "Bob", "nameGreen": "Alice", "games": [{"wonPoints": 2, "scoreGreen": 0}]}
+	smileData := []byte{0xfa, 0x86, 'n', 'a', 'm', 'e', 'R', 'e', 'd', 0x42, 'B', 'o', 'b'}
+	smileData = append(smileData, 0x88, 'n', 'a', 'm', 'e', 'G', 'r', 'e', 'e', 'n', 0x44, 'A', 'l', 'i', 'c', 'e')
+	smileData = append(smileData, 0x84, 'g', 'a', 'm', 'e', 's', 0xf8, 0xfa)
+	smileData = append(smileData, 0x88, 'w', 'o', 'n', 'P', 'o', 'i', 'n', 't', 's', 0xc4)
+	smileData = append(smileData, 0x89, 's', 'c', 'o', 'r', 'e', 'G', 'r', 'e', 'e', 'n', 0xc0, 0xfb, 0xf9, 0xfb)
+
+	tests := []struct {
+		name string
+		data io.Reader
+	}{
+		{"SMILE", smileBGF(0x00, smileData)},
+		{"JSON", strings.NewReader(`{"format":"BGF","version":"1.0","compress":false,"useSmile":false}` + "\n" +
+			`{"nameRed":"Bob","nameGreen":"Alice","games":[{"wonPoints":2,"scoreGreen":0}]}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, err := ParseBGFFromReaderWithOptions(tt.data, BGFOptions{PreserveKeyOrder: true})
+			if err != nil {
+				t.Fatalf("ParseBGFFromReaderWithOptions failed: %v", err)
+			}
+			if match.OrderedData == nil {
+				t.Fatal("OrderedData not set")
+			}
+
+			if want := []string{"nameRed", "nameGreen", "games"}; !reflect.DeepEqual(match.OrderedData.Keys, want) {
+				t.Errorf("Keys = %v, want %v", match.OrderedData.Keys, want)
+			}
+			games := match.OrderedData.Values["games"].([]interface{})
+			game := games[0].(*OrderedMap)
+			if want := []string{"wonPoints", "scoreGreen"}; !reflect.DeepEqual(game.Keys, want) {
+				t.Errorf("Game keys = %v, want %v", game.Keys, want)
+			}
+
+			// Data stays a plain map for the other accessors
+			if match.Data["nameGreen"] != "Alice" || len(match.Games()) != 1 || match.Games()[0].WonPoints != 2 {
+				t.Errorf("Unexpected Data: %v", match.Data)
+			}
+
+			jsonData, err := match.ToJSON()
+			if err != nil {
+				t.Fatalf("ToJSON failed: %v", err)
+			}
+			out := string(jsonData)
+			if !(strings.Index(out, `"nameRed"`) < strings.Index(out, `"nameGreen"`) &&
+				strings.Index(out, `"nameGreen"`) < strings.Index(out, `"games"`) &&
+				strings.Index(out, `"wonPoints"`) < strings.Index(out, `"scoreGreen"`)) {
+				t.Errorf("ToJSON does not keep the key order:\n%s", out)
+			}
+			if !strings.Contains(out, `"format": "BGF"`) {
+				t.Errorf("ToJSON lost the header fields:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestParseBGFFromReader_CompressSmileCombinations(t *testing.T) {
+	compressed, err := os.ReadFile("test/fixtures/compressed_smile.bgf")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	smileData, _, err := decompressGzip(compressed[bytes.IndexByte(compressed, '\n')+1:], new(bytes.Buffer), 0)
+	if err != nil {
+		t.Fatalf("decompressGzip failed: %v", err)
+	}
+	jsonData, err := os.ReadFile("test/fixtures/compressed_smile.json")
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	gzipped := func(data []byte) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(data)
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name     string
+		compress bool
+		useSmile bool
+		payload  []byte
+	}{
+		{"plain JSON", false, false, jsonData},
+		{"compressed JSON", true, false, gzipped(jsonData)},
+		{"plain SMILE", false, true, smileData},
+		{"compressed SMILE", true, true, smileData},
+	}
+	tests[3].payload = gzipped(smileData)
+
+	var want []byte
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := fmt.Sprintf(`{"format":"BGF","version":"1.0","compress":%v,"useSmile":%v}`+"\n", tt.compress, tt.useSmile)
+			match, err := ParseBGFFromReader(bytes.NewReader(append([]byte(header), tt.payload...)))
+			if err != nil {
+				t.Fatalf("ParseBGFFromReader failed: %v", err)
+			}
+			if match.Compress != tt.compress || match.UseSmile != tt.useSmile {
+				t.Errorf("Compress/UseSmile = %v/%v, want %v/%v", match.Compress, match.UseSmile, tt.compress, tt.useSmile)
+			}
+			if len(match.DecodingWarnings) > 0 {
+				t.Errorf("Unexpected warnings: %v", match.DecodingWarnings)
+			}
+
+			// JSON numbers decode as float64 and SMILE integers as int64,
+			// so Data is compared through its canonical form
+			got, err := (&Match{Data: match.Data}).ToCanonicalJSON()
+			if err != nil {
+				t.Fatalf("ToCanonicalJSON failed: %v", err)
+			}
+			if want == nil {
+				want = got
+			} else if !bytes.Equal(got, want) {
+				t.Errorf("Decoded Data differs from plain JSON:\n%s\nwant:\n%s", got, want)
+			}
+			if len(match.Games()) != 6 {
+				t.Errorf("Got %d games, want 6", len(match.Games()))
+			}
+		})
+	}
+}
+
+func TestDisassembleSMILE(t *testing.T) {
+	// {"a": 1, "list": [-300, "xy", 1.5], "b": "xy"} with shared values
+	smileData := []byte(":)\n\x03")
+	smileData = append(smileData, 0xfa, 0x80, 'a', 0xc2)
+	smileData = append(smileData, 0x83, 'l', 'i', 's', 't', 0xf8, 0x24, 0x09, 0x97, 0x41, 'x', 'y')
+	smileData = append(smileData, 0x29, 0x00, 0x3f, 0x7c, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf9)
+	smileData = append(smileData, 0x80, 'b', 0x01, 0xfb)
+
+	var buf bytes.Buffer
+	if err := DisassembleSMILE(&buf, smileData); err != nil {
+		t.Fatalf("DisassembleSMILE failed: %v", err)
+	}
+
+	want := `     0  3a 29 0a 03                 header         version 0, shared keys, shared values
+     4  fa                          start object
+     5  80 61                       key            "a"
+     7  c2                          small int      1
+     8  83 6c 69 73 74              key            "list"
+    13  f8                          start array
+    14  24 09 97                    int32          -300
+    17  41 78 79                    short ascii    "xy"
+    20  29 00 3f 7c 00 00 00 00 ... float64        1.5
+    31  f9                          end array
+    32  80 62                       key            "b"
+    34  01                          shared value   "xy"
+    35  fb                          end object
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Listing mismatch:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Unbalanced containers are reported with their offset
+	if err := DisassembleSMILE(io.Discard, []byte(":)\n\x00\xf8\xfb")); err == nil || !strings.Contains(err.Error(), "offset 5") {
+		t.Errorf("Expected unbalanced container error at offset 5, got %v", err)
+	}
+}
+
+func TestParseBGFFromReader_SMILESharedStrings(t *testing.T) {
+	// [{"name": "Alice"}, {"name": "Alice"}] with the second key and value
+	// written as back-references
+	body := []byte{0xf8, 0xfa, 0x83, 'n', 'a', 'm', 'e', 0x44, 'A', 'l', 'i', 'c', 'e', 0xfb}
+	body = append(body, 0xfa, 0x40, 0x01, 0xfb, 0xf9)
+
+	match, err := ParseBGFFromReader(smileBGF(0x03, body))
+	if err != nil {
+		t.Fatalf("ParseBGFFromReader failed: %v", err)
+	}
+	items, _ := match.RawValue.([]interface{})
+	if len(items) != 2 {
+		t.Fatalf("RawValue = %v, want 2 objects", match.RawValue)
+	}
+	for i, item := range items {
+		obj, _ := item.(map[string]interface{})
+		if obj["name"] != "Alice" {
+			t.Errorf("Item %d = %v, want name Alice", i, item)
+		}
+	}
+
+	// A reference to a value never seen is an error, not a panic
+	bad := []byte(":)\n\x03\xfa\x80a\x05\xfb")
+	var v interface{}
+	if err := smile.Unmarshal(bad, &v); err == nil {
+		t.Error("Expected error for an invalid shared value reference")
+	}
+}
+
+// endlessReader yields an endless stream of the same byte, counting what was read
+type endlessReader struct {
+	b    byte
+	read int64
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.b
+	}
+	r.read += int64(len(p))
+	return len(p), nil
+}
+
+func TestParseOptions_MaxBytes(t *testing.T) {
+	const maxBytes = 64 << 10
+	// Readers fill at most one read buffer past the limit before failing
+	const slack = 64 << 10
+
+	t.Run("BGF", func(t *testing.T) {
+		header := `{"format":"BGF","version":"1.0","compress":false,"useSmile":false}` + "\n"
+		body := &endlessReader{b: ' '}
+		opts := BGFOptions{ParseOptions: ParseOptions{MaxBytes: maxBytes}}
+		_, err := ParseBGFFromReaderWithOptions(io.MultiReader(strings.NewReader(header), body), opts)
+		if !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("err = %v, want ErrTooLarge", err)
+		}
+		if body.read > maxBytes+slack {
+			t.Errorf("Read %d bytes, want at most %d", body.read, maxBytes+slack)
+		}
+	})
+
+	t.Run("BGF gzip bomb", func(t *testing.T) {
+		// 8 MiB of spaces compress to far less than the limit
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		gz.Write(bytes.Repeat([]byte{' '}, 8<<20))
+		gz.Close()
+		if compressed.Len() > maxBytes/2 {
+			t.Fatalf("Compressed to %d bytes, want well within the limit", compressed.Len())
+		}
+
+		header := `{"format":"BGF","version":"1.0","compress":true,"useSmile":false}` + "\n"
+		opts := BGFOptions{ParseOptions: ParseOptions{MaxBytes: maxBytes}}
+		_, err := ParseBGFFromReaderWithOptions(io.MultiReader(strings.NewReader(header), &compressed), opts)
+		if !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("err = %v, want ErrTooLarge", err)
+		}
+	})
+
+	t.Run("BGF header", func(t *testing.T) {
+		body := &endlessReader{b: '{'}
+		opts := BGFOptions{ParseOptions: ParseOptions{MaxBytes: maxBytes}}
+		if _, err := ParseBGFFromReaderWithOptions(body, opts); !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("err = %v, want ErrTooLarge", err)
+		}
+	})
+
+	t.Run("TXT", func(t *testing.T) {
+		body := &endlessReader{b: '\n'}
+		opts := TXTOptions{ParseOptions: ParseOptions{MaxBytes: maxBytes}}
+		_, err := ParseTXTFromReaderWithOptions(body, opts)
+		if !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("err = %v, want ErrTooLarge", err)
+		}
+		if body.read > maxBytes+slack {
+			t.Errorf("Read %d bytes, want at most %d", body.read, maxBytes+slack)
+		}
+	})
+
+	t.Run("Within limit", func(t *testing.T) {
+		data, err := os.ReadFile("test/2025-11-04/01_checkerPosition_EN.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := TXTOptions{ParseOptions: ParseOptions{MaxBytes: int64(len(data))}}
+		if _, err := ParseTXTFromReaderWithOptions(bytes.NewReader(data), opts); err != nil {
+			t.Errorf("ParseTXTFromReaderWithOptions failed at exactly MaxBytes: %v", err)
+		}
+		opts.MaxBytes--
+		if _, err := ParseTXTFromReaderWithOptions(bytes.NewReader(data), opts); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("err = %v, want ErrTooLarge one byte over MaxBytes", err)
+		}
+	})
+}
@@ -0,0 +1,213 @@
+package bgfparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// xgidMaxCube is the maximum cube field written by ToXGID when the position
+// has no XGID to take it from (2^10 = 1024, as BGBlitz exports)
+const xgidMaxCube = 10
+
+// xgidMaxCount is the largest checker count of a point or bar in an XGID board ('O')
+const xgidMaxCount = 15
+
+// xgidFields is the number of ":"-separated fields of an XGID
+const xgidFields = 10
+
+// validateXGID checks the fields of an XGID (without the "XGID=" prefix): their
+// count, that all but the board and dice are numbers within range, and that the
+// dice are two digits. It returns the numeric fields, 0 for the board and dice.
+func validateXGID(xgid string) ([]int, error) {
+	parts := strings.Split(xgid, ":")
+	if len(parts) != xgidFields {
+		return nil, fmt.Errorf("invalid XGID %q: expected %d fields, got %d", xgid, xgidFields, len(parts))
+	}
+
+	fields := make([]int, len(parts))
+	for i := 1; i < len(parts); i++ {
+		if i == 4 {
+			continue // Dice
+		}
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid XGID %q: field %d is not a number: %q", xgid, i+1, parts[i])
+		}
+		fields[i] = n
+	}
+	switch {
+	case fields[1] < 0 || fields[1] > 12:
+		return nil, fmt.Errorf("invalid XGID %q: cube value 2^%d out of range", xgid, fields[1])
+	case fields[2] < -1 || fields[2] > 1:
+		return nil, fmt.Errorf("invalid XGID %q: cube owner %d must be -1, 0 or 1", xgid, fields[2])
+	case fields[3] != 1 && fields[3] != -1:
+		return nil, fmt.Errorf("invalid XGID %q: player on roll %d must be 1 or -1", xgid, fields[3])
+	case fields[5] < 0 || fields[6] < 0 || fields[8] < 0:
+		return nil, fmt.Errorf("invalid XGID %q: negative score or match length", xgid)
+	}
+
+	dice := parts[4]
+	if len(dice) != 2 || dice[0] < '0' || dice[0] > '6' || dice[1] < '0' || dice[1] > '6' || (dice[0] == '0') != (dice[1] == '0') {
+		return nil, fmt.Errorf("invalid XGID %q: invalid dice %q", xgid, dice)
+	}
+	return fields, nil
+}
+
+// ParseXGID builds a position from an XGID string alone: board, bar, cube,
+// player on roll, dice, scores, match length and Crawford state, plus the
+// pip counts and borne-off checkers derived from the board. All 10 fields
+// are required, and a malformed XGID returns a ParseError.
+func ParseXGID(xgid string) (*Position, error) {
+	xgid = strings.TrimPrefix(strings.TrimSpace(xgid), "XGID=")
+
+	pos := &Position{
+		OnBar:    make(map[string]int),
+		PipCount: make(map[string]int),
+		Off:      make(map[string]int),
+		XGID:     xgid,
+	}
+	if err := parseXGID(pos, xgid); err != nil {
+		return nil, &ParseError{Message: err.Error()}
+	}
+	dice := strings.Split(xgid, ":")[4]
+	pos.Dice = Dice{int(dice[0] - '0'), int(dice[1] - '0')}
+
+	for _, player := range []string{"X", "O"} {
+		pos.PipCount[player] = pos.computePipCount(player)
+		pos.Off[player] = pos.BorneOff(player)
+	}
+	updateCrawfordState(pos)
+
+	return pos, nil
+}
+
+// ToXGID encodes the position as an XGID string
+// (board:cubeValue:cubeOwner:onRoll:dice:scoreX:scoreO:crawford:matchLength:maxCube).
+// Dice are written with the higher die first, "00" when not rolled.
+// In money games the Crawford field holds the Jacoby rule, and the maximum
+// cube of the XGID the position was read from is kept. A position with more
+// than 15 checkers on a point or bar cannot be encoded and yields "".
+func (p *Position) ToXGID() string {
+	return strings.Join(p.xgidFields(), ":")
+}
+
+// CanonicalKey returns a stable key identifying the position: the board,
+// player on roll, dice, cube state, score and match length, encoded like ToXGID.
+// Player names, identifiers and evaluations are ignored, so the same position
+// exported in different languages or by different tools yields the same key.
+func (p *Position) CanonicalKey() string {
+	fields := p.xgidFields()
+	if fields == nil {
+		return ""
+	}
+	return strings.Join(fields[:len(fields)-1], ":")
+}
+
+// Equal reports whether both positions have the same CanonicalKey. Positions
+// that cannot be encoded are only equal to themselves.
+func (p *Position) Equal(other *Position) bool {
+	if p == nil || other == nil || p == other {
+		return p == other
+	}
+	key := p.CanonicalKey()
+	return key != "" && key == other.CanonicalKey()
+}
+
+// xgidFields returns the ten XGID fields describing the position, or nil
+// when the board cannot be encoded
+func (p *Position) xgidFields() []string {
+	board, ok := p.encodeXGIDBoard()
+	if !ok {
+		return nil
+	}
+
+	cubeLog := 0
+	for v := p.CubeValue; v > 1; v >>= 1 {
+		cubeLog++
+	}
+
+	owner := "0"
+	if !p.CubeIsCentered() {
+		switch p.CubeOwner {
+		case "X":
+			owner = "1"
+		case "O":
+			owner = "-1"
+		}
+	}
+
+	turn := "0"
+	switch p.OnRoll {
+	case "X":
+		turn = "1"
+	case "O":
+		turn = "-1"
+	}
+
+	dice := "00"
+	if p.Dice[0] > 0 && p.Dice[1] > 0 {
+		high, low := p.Dice[0], p.Dice[1]
+		if low > high {
+			high, low = low, high
+		}
+		dice = fmt.Sprintf("%d%d", high, low)
+	}
+
+	// The Crawford game in match play, the Jacoby rule in money games
+	crawford := "0"
+	if (p.MatchLength > 0 && p.Crawford) || (p.MatchLength == 0 && p.Rules.Jacoby) {
+		crawford = "1"
+	}
+
+	maxCube := xgidMaxCube
+	if parts := strings.Split(strings.TrimPrefix(p.XGID, "XGID="), ":"); len(parts) == xgidFields {
+		if n, err := strconv.Atoi(parts[9]); err == nil && n > 0 {
+			maxCube = n
+		}
+	}
+
+	return []string{
+		board,
+		fmt.Sprint(cubeLog),
+		owner,
+		turn,
+		dice,
+		fmt.Sprint(p.ScoreX),
+		fmt.Sprint(p.ScoreO),
+		crawford,
+		fmt.Sprint(p.MatchLength),
+		fmt.Sprint(maxCube),
+	}
+}
+
+// encodeXGIDBoard encodes Board and OnBar in the 26-character XGID board
+// format decoded by parseXGIDBoard, reporting false when a count exceeds 15
+func (p *Position) encodeXGIDBoard() (string, bool) {
+	counts := make([]int, 0, 26)
+	counts = append(counts, -p.OnBar["O"])
+	counts = append(counts, p.Board[1:25]...)
+	counts = append(counts, p.OnBar["X"])
+
+	var b strings.Builder
+	b.Grow(len(counts))
+	for _, count := range counts {
+		if count > xgidMaxCount || count < -xgidMaxCount {
+			return "", false
+		}
+		b.WriteByte(xgidChar(count))
+	}
+	return b.String(), true
+}
+
+// xgidChar encodes a signed checker count (positive for X, negative for O)
+// between -15 and 15
+func xgidChar(count int) byte {
+	switch {
+	case count > 0:
+		return byte('A' + count - 1)
+	case count < 0:
+		return byte('a' - count - 1)
+	}
+	return '-'
+}